@@ -0,0 +1,211 @@
+package safesonnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditStage identifies where in the import resolution an AuditEvent was recorded.
+type AuditStage string
+
+const (
+	// AuditStageImport covers decisions about the import as a whole, such as
+	// input validation and the final not-found result.
+	AuditStageImport AuditStage = "import"
+	// AuditStageHook covers imports vetoed or injected by hooks.
+	AuditStageHook AuditStage = "hook"
+	// AuditStagePrimary covers the lookup relative to the importing file.
+	AuditStagePrimary AuditStage = "primary"
+	// AuditStageJPath covers lookups in library paths.
+	AuditStageJPath AuditStage = "jpath"
+)
+
+// AuditDecision is the outcome recorded in an AuditEvent.
+type AuditDecision string
+
+const (
+	// AuditAllow means the file was found and returned.
+	AuditAllow AuditDecision = "allow"
+	// AuditDeny means the import was refused or the file could not be read.
+	AuditDeny AuditDecision = "deny"
+	// AuditMiss means the file did not exist at the candidate location.
+	AuditMiss AuditDecision = "miss"
+)
+
+// AuditEvent records a single import decision.
+type AuditEvent struct {
+	Time         time.Time     `json:"time"`
+	Stage        AuditStage    `json:"stage"`
+	ImportedFrom string        `json:"importedFrom"`
+	ImportedPath string        `json:"importedPath"`
+	ResolvedPath string        `json:"resolvedPath,omitempty"`
+	JPath        string        `json:"jpath,omitempty"`
+	CacheHit     bool          `json:"cacheHit"`
+	Decision     AuditDecision `json:"decision"`
+	// Error is the message of the sentinel error matching Err, if any.
+	Error string `json:"error,omitempty"`
+	// Err is the full error behind a deny or miss decision.
+	Err error `json:"-"`
+}
+
+// AuditSink receives every import decision. Implementations must be safe for
+// concurrent use.
+type AuditSink interface {
+	Audit(ev AuditEvent)
+}
+
+// WithAuditSink sends import decisions to sink.
+func WithAuditSink(sink AuditSink) Option {
+	return func(s *SafeImporter) {
+		s.auditSink = sink
+	}
+}
+
+func (s *SafeImporter) audit(ev AuditEvent) {
+	if s.auditSink == nil {
+		return
+	}
+
+	ev.Time = time.Now()
+	if sentinel := sentinelOf(ev.Err); sentinel != nil {
+		ev.Error = sentinel.Error()
+	} else if ev.Err != nil {
+		ev.Error = ev.Err.Error()
+	}
+
+	s.auditSink.Audit(ev)
+}
+
+// recordLookup reports the outcome of loading a single candidate file.
+func (s *SafeImporter) recordLookup(
+	ctx context.Context,
+	stage AuditStage,
+	r *resolution,
+	candidate, jpath string,
+	res fileLookup,
+	err error,
+) {
+	r.add(newCandidate(candidate, jpath, res, err))
+	s.logLookup(ctx, candidate, jpath, res, err)
+	s.metrics.CacheLookup(res.cached)
+
+	ev := AuditEvent{
+		Stage:        stage,
+		ImportedFrom: r.importedFrom,
+		ImportedPath: r.importedPath,
+		ResolvedPath: candidate,
+		JPath:        jpath,
+		CacheHit:     res.cached,
+		Decision:     AuditMiss,
+		Err:          err,
+	}
+	switch {
+	case err != nil:
+		ev.Decision = AuditDeny
+	case res.found:
+		ev.Decision = AuditAllow
+	}
+
+	s.audit(ev)
+}
+
+// auditOutsideRoot records a candidate that was refused without being probed
+// because it is outside the root. err is the resulting violation, or nil when
+// the search continues with the next candidate.
+func (s *SafeImporter) auditOutsideRoot(stage AuditStage, r *resolution, candidate, jpath string, err error) {
+	s.audit(AuditEvent{
+		Stage:        stage,
+		ImportedFrom: r.importedFrom,
+		ImportedPath: r.importedPath,
+		ResolvedPath: candidate,
+		JPath:        jpath,
+		Decision:     AuditDeny,
+		Err:          err,
+	})
+}
+
+// sentinelOf returns the package sentinel error wrapped by err, or nil.
+func sentinelOf(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, sentinel := range []error{
+		ErrInvalidNullByte,
+		ErrForbiddenAbsolutePath,
+		ErrForbiddenRelativePathTraversal,
+		ErrForbiddenPathTraversal,
+		ErrFileNotFound,
+		ErrWorldWritable,
+		ErrFileOwner,
+		ErrImportRejected,
+		ErrReadTimeout,
+		ErrReadFile,
+	} {
+		if errors.Is(err, sentinel) {
+			return sentinel
+		}
+	}
+
+	return nil
+}
+
+// JSONLinesSink is an AuditSink writing one JSON object per line.
+type JSONLinesSink struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	err    error
+}
+
+// NewJSONLinesSink returns a sink writing events to w.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{enc: json.NewEncoder(w)}
+}
+
+// OpenJSONLinesFile returns a sink appending events to the file at path,
+// creating it if needed. Close releases the file.
+func OpenJSONLinesFile(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := NewJSONLinesSink(f)
+	sink.closer = f
+
+	return sink, nil
+}
+
+// Audit writes ev as a single line. Write failures are retained and reported
+// by Err, since import resolution cannot act on them.
+func (j *JSONLinesSink) Audit(ev AuditEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.enc.Encode(ev); err != nil && j.err == nil {
+		j.err = err
+	}
+}
+
+// Err returns the first write error, if any.
+func (j *JSONLinesSink) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.err
+}
+
+// Close closes the underlying file when the sink was created by OpenJSONLinesFile.
+func (j *JSONLinesSink) Close() error {
+	if j.closer == nil {
+		return nil
+	}
+
+	return j.closer.Close()
+}
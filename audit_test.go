@@ -0,0 +1,150 @@
+package safesonnet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (r *recordingSink) Audit(ev AuditEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, ev)
+}
+
+func (r *recordingSink) take() []AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events
+	r.events = nil
+
+	return events
+}
+
+func TestImport_Audit(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "util.libsonnet"), `{}`)
+
+	sink := &recordingSink{}
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"}, WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	from := filepath.Join(tmpDir, "main.jsonnet")
+	if _, _, err := imp.Import(from, "util.libsonnet"); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	events := sink.take()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if ev := events[0]; ev.Stage != AuditStagePrimary || ev.Decision != AuditMiss {
+		t.Errorf("first event = %+v, want primary miss", ev)
+	}
+	ev := events[1]
+	if ev.Stage != AuditStageJPath || ev.Decision != AuditAllow || ev.JPath != "lib" || ev.CacheHit {
+		t.Errorf("second event = %+v, want uncached jpath allow from lib", ev)
+	}
+
+	if _, _, err := imp.Import(from, "util.libsonnet"); err != nil {
+		t.Fatalf("second Import() error = %v", err)
+	}
+	if events := sink.take(); len(events) != 2 || !events[0].CacheHit || !events[1].CacheHit {
+		t.Errorf("second import events = %+v, want cache hits", events)
+	}
+
+	if _, _, err := imp.Import(from, "../escape.jsonnet"); err == nil {
+		t.Fatal("Import() traversal should fail")
+	}
+	events = sink.take()
+	wantErr := ErrForbiddenRelativePathTraversal.Error()
+	if len(events) != 1 || events[0].Decision != AuditDeny || events[0].Error != wantErr {
+		t.Errorf("traversal events = %+v, want a single deny with the traversal sentinel", events)
+	}
+
+	if _, _, err := imp.Import(from, "missing.libsonnet"); err == nil {
+		t.Fatal("Import() missing file should fail")
+	}
+	events = sink.take()
+	if last := events[len(events)-1]; last.Stage != AuditStageImport || last.Decision != AuditMiss {
+		t.Errorf("last missing-file event = %+v, want import miss", last)
+	}
+}
+
+func TestImport_AuditOutsideRoot(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	sink := &recordingSink{}
+	imp, err := NewSafeImporter(tmpDir, nil, WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	// The entrypoint resolves outside the root both relative to the working
+	// directory and relative to the "." JPath.
+	if _, _, err := imp.Import("", "../outside.jsonnet"); err == nil {
+		t.Fatal("Import() outside root should fail")
+	}
+
+	events := sink.take()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if ev := events[0]; ev.Stage != AuditStagePrimary || ev.Decision != AuditDeny || ev.ResolvedPath == "" {
+		t.Errorf("first event = %+v, want primary deny with the resolved path", ev)
+	}
+	wantPath := filepath.Join(filepath.Dir(tmpDir), "outside.jsonnet")
+	if ev := events[1]; ev.Stage != AuditStageJPath || ev.Decision != AuditDeny ||
+		ev.JPath != "." || ev.ResolvedPath != wantPath {
+		t.Errorf("second event = %+v, want jpath deny for %s", ev, wantPath)
+	}
+	if ev := events[2]; ev.Stage != AuditStageImport || ev.Decision != AuditMiss {
+		t.Errorf("last event = %+v, want import miss", ev)
+	}
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	sink := NewJSONLinesSink(&buf)
+	sink.Audit(AuditEvent{Stage: AuditStageImport, ImportedPath: "a.jsonnet", Decision: AuditDeny, Error: "boom"})
+	sink.Audit(AuditEvent{Stage: AuditStagePrimary, ImportedPath: "b.jsonnet", Decision: AuditAllow})
+	if err := sink.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	var lines []map[string]any
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var m map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0]["importedPath"] != "a.jsonnet" || lines[0]["decision"] != "deny" || lines[0]["error"] != "boom" {
+		t.Errorf("first line = %v", lines[0])
+	}
+}
@@ -0,0 +1,229 @@
+package safesonnet
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultBundlerVendorDir is the directory jsonnet-bundler installs
+// dependencies into unless told otherwise.
+const DefaultBundlerVendorDir = "vendor"
+
+const (
+	jsonnetfileName     = "jsonnetfile.json"
+	jsonnetfileLockName = "jsonnetfile.lock.json"
+)
+
+// jsonnetfile is the subset of jsonnetfile.json and jsonnetfile.lock.json
+// needed to locate installed dependencies.
+type jsonnetfile struct {
+	Dependencies []jbDependency `json:"dependencies"`
+	// LegacyImports defaults to true, as in jsonnet-bundler.
+	LegacyImports *bool `json:"legacyImports"`
+}
+
+type jbDependency struct {
+	Source struct {
+		Git *struct {
+			Remote string `json:"remote"`
+			Subdir string `json:"subdir"`
+		} `json:"git"`
+		Local *struct {
+			Directory string `json:"directory"`
+		} `json:"local"`
+	} `json:"source"`
+	// Name overrides the legacy import name.
+	Name string `json:"name"`
+}
+
+// WithJsonnetBundler configures JPaths for dependencies managed by
+// jsonnet-bundler. It reads jsonnetfile.lock.json, or jsonnetfile.json when
+// there is no lock file, from the root directory and appends vendorDir, or
+// DefaultBundlerVendorDir when empty, to the JPaths. With legacy imports
+// enabled, a dependency without its legacy vendor/<name> symlink gets its
+// parent directory added instead, so "name/file.libsonnet" imports still
+// resolve.
+//
+// NewSafeImporter fails if the jsonnetfile cannot be read, a dependency is
+// not installed, or a dependency, its legacy symlink or a local source
+// resolves outside the root.
+func WithJsonnetBundler(vendorDir string) Option {
+	return func(s *SafeImporter) {
+		if vendorDir == "" {
+			vendorDir = DefaultBundlerVendorDir
+		}
+		s.bundlerVendorDir = vendorDir
+	}
+}
+
+// configureBundler applies WithJsonnetBundler after all options, since
+// options cannot return errors.
+func (s *SafeImporter) configureBundler() error {
+	vendor, err := resolveJPath(s.bundlerVendorDir, s.rootAbsPath)
+	if err != nil {
+		return err
+	}
+
+	jf, err := s.readJsonnetfile()
+	if err != nil {
+		return err
+	}
+	legacy := jf.LegacyImports == nil || *jf.LegacyImports
+
+	jpaths := []string{vendor}
+	for _, dep := range jf.Dependencies {
+		legacyPath, err := s.checkJBDependency(vendor, dep, legacy)
+		if err != nil {
+			return err
+		}
+		if legacyPath != "" {
+			jpaths = append(jpaths, legacyPath)
+		}
+	}
+
+	for _, jp := range jpaths {
+		if !slices.Contains(s.JPaths, jp) {
+			s.JPaths = append(s.JPaths, jp)
+		}
+	}
+
+	return nil
+}
+
+func (s *SafeImporter) readJsonnetfile() (*jsonnetfile, error) {
+	name := jsonnetfileLockName
+	data, err := s.root.ReadFile(name)
+	if os.IsNotExist(err) {
+		name = jsonnetfileName
+		data, err = s.root.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJsonnetfile, err)
+	}
+
+	var jf jsonnetfile
+	if err := json.Unmarshal(data, &jf); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrJsonnetfile, name, err)
+	}
+
+	return &jf, nil
+}
+
+// checkJBDependency validates an installed dependency and returns the JPath
+// its legacy imports need, if any.
+func (s *SafeImporter) checkJBDependency(vendor string, dep jbDependency, legacy bool) (string, error) {
+	switch {
+	case dep.Source.Git != nil:
+		pkg := path.Join(gitPackagePath(dep.Source.Git.Remote), dep.Source.Git.Subdir)
+		installed, err := dependencyPath(vendor, pkg)
+		if err != nil {
+			return "", err
+		}
+		if err := s.checkDependencyDir(installed); err != nil {
+			return "", err
+		}
+		if !legacy {
+			return "", nil
+		}
+
+		return s.checkLegacyImport(vendor, installed, cmp.Or(dep.Name, path.Base(pkg)))
+	case dep.Source.Local != nil:
+		return "", s.checkLocalDependency(vendor, dep)
+	default:
+		return "", fmt.Errorf("%w: dependency %q has no git or local source", ErrJsonnetfile, dep.Name)
+	}
+}
+
+// checkLocalDependency validates a local source and the vendor symlink
+// jsonnet-bundler links it to.
+func (s *SafeImporter) checkLocalDependency(vendor string, dep jbDependency) error {
+	dir := filepath.FromSlash(dep.Source.Local.Directory)
+	rel, inside, err := relToRoot(s.rootAbsPath, filepath.Join(s.rootAbsPath, dir))
+	if err != nil || !inside {
+		return fmt.Errorf("%w: local source %q", ErrDependencyOutsideRoot, dep.Source.Local.Directory)
+	}
+	if err := s.checkDependencyDir(rel); err != nil {
+		return err
+	}
+
+	installed, err := dependencyPath(vendor, cmp.Or(dep.Name, filepath.Base(dir)))
+	if err != nil {
+		return err
+	}
+
+	return s.checkDependencyDir(installed)
+}
+
+// checkLegacyImport validates the legacy vendor/<name> symlink of a
+// dependency installed at installed. When the link is missing it returns the
+// parent of installed, which serves "name/..." imports if the installed
+// directory is itself called name.
+func (s *SafeImporter) checkLegacyImport(vendor, installed, name string) (string, error) {
+	link, err := dependencyPath(vendor, name)
+	if err != nil {
+		return "", err
+	}
+	if link == installed {
+		return "", nil
+	}
+
+	if _, err := s.root.Lstat(link); err == nil {
+		return "", s.checkDependencyDir(link)
+	}
+	if filepath.Base(installed) == name {
+		return filepath.Dir(installed), nil
+	}
+
+	return "", nil
+}
+
+// checkDependencyDir checks that rel is a directory inside the root, following
+// symlinks through the root.
+func (s *SafeImporter) checkDependencyDir(rel string) error {
+	fi, err := s.root.Stat(rel)
+	switch {
+	case os.IsNotExist(err):
+		return fmt.Errorf("%w: %q", ErrDependencyNotInstalled, rel)
+	case err != nil:
+		return fmt.Errorf("%w: %q: %w", ErrDependencyOutsideRoot, rel, err)
+	case !fi.IsDir():
+		return fmt.Errorf("%w: %q is not a directory", ErrDependencyNotInstalled, rel)
+	}
+
+	return nil
+}
+
+// dependencyPath joins a slash-separated package path to the vendor directory,
+// rejecting paths that leave it.
+func dependencyPath(vendor, pkg string) (string, error) {
+	p := filepath.FromSlash(pkg)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w: package path %q", ErrDependencyOutsideRoot, pkg)
+	}
+
+	return filepath.Join(vendor, p), nil
+}
+
+// gitPackagePath returns the host/path a git remote is installed under, such
+// as "github.com/grafana/jsonnet-libs" for both
+// "https://github.com/grafana/jsonnet-libs.git" and
+// "git@github.com:grafana/jsonnet-libs.git".
+func gitPackagePath(remote string) string {
+	r := remote
+	if _, rest, ok := strings.Cut(r, "://"); ok {
+		r = rest
+	} else {
+		r = strings.Replace(r, ":", "/", 1)
+	}
+	if at := strings.Index(r, "@"); at >= 0 && at < strings.Index(r+"/", "/") {
+		r = r[at+1:]
+	}
+
+	return strings.Trim(strings.TrimSuffix(r, ".git"), "/")
+}
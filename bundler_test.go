@@ -0,0 +1,179 @@
+package safesonnet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testJsonnetfile = `{
+  "version": 1,
+  "dependencies": [
+    {
+      "source": {"git": {"remote": "https://github.com/grafana/jsonnet-libs.git", "subdir": "grafonnet"}},
+      "version": "master"
+    },
+    {
+      "source": {"git": {"remote": "git@github.com:jsonnet-libs/k8s-libsonnet.git", "subdir": "1.30"}},
+      "version": "main",
+      "name": "k8s"
+    },
+    {
+      "source": {"local": {"directory": "shared"}},
+      "version": ""
+    }
+  ],
+  "legacyImports": true
+}`
+
+func TestWithJsonnetBundler(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "jsonnetfile.json"), testJsonnetfile)
+	grafonnet := filepath.Join(tmpDir, "vendor", "github.com", "grafana", "jsonnet-libs", "grafonnet")
+	k8s := filepath.Join(tmpDir, "vendor", "github.com", "jsonnet-libs", "k8s-libsonnet", "1.30")
+	mustWriteFile(t, filepath.Join(grafonnet, "main.libsonnet"), `'grafonnet'`)
+	mustWriteFile(t, filepath.Join(k8s, "main.libsonnet"), `'k8s'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "shared", "main.libsonnet"), `'shared'`)
+	// jsonnet-bundler links legacy names and local sources into vendor/.
+	if err := os.Symlink(filepath.Join("github.com", "jsonnet-libs", "k8s-libsonnet", "1.30"),
+		filepath.Join(tmpDir, "vendor", "k8s")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("..", "shared"), filepath.Join(tmpDir, "vendor", "shared")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `[
+  import 'github.com/grafana/jsonnet-libs/grafonnet/main.libsonnet',
+  import 'grafonnet/main.libsonnet',
+  import 'k8s/main.libsonnet',
+  import 'shared/main.libsonnet',
+]`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"."}, WithJsonnetBundler(""))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	// grafonnet has no legacy symlink, so its parent serves the legacy import.
+	wantJPaths := []string{".", "vendor", filepath.Join("vendor", "github.com", "grafana", "jsonnet-libs")}
+	if !slices.Equal(imp.JPaths, wantJPaths) {
+		t.Errorf("JPaths = %v, want %v", imp.JPaths, wantJPaths)
+	}
+
+	out, err := NewVM(imp, VMOptions{}).EvaluateFile(filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+	if want := "[\n   \"grafonnet\",\n   \"grafonnet\",\n   \"k8s\",\n   \"shared\"\n]\n"; out != want {
+		t.Errorf("EvaluateFile() = %q, want %q", out, want)
+	}
+}
+
+func TestWithJsonnetBundler_LockFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	// The lock file lists the transitive dependency; jsonnetfile.json does not.
+	mustWriteFile(t, filepath.Join(tmpDir, "jsonnetfile.json"), `{"version": 1, "dependencies": []}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "jsonnetfile.lock.json"), `{"version": 1, "dependencies": [
+  {"source": {"git": {"remote": "https://example.com/org/lib", "subdir": ""}}, "version": "v1"}
+], "legacyImports": false}`)
+
+	_, err := NewSafeImporter(tmpDir, nil, WithJsonnetBundler("vendor"))
+	if !errors.Is(err, ErrDependencyNotInstalled) {
+		t.Fatalf("NewSafeImporter() error = %v, want ErrDependencyNotInstalled", err)
+	}
+
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "example.com", "org", "lib", "main.libsonnet"), `{}`)
+	imp, err := NewSafeImporter(tmpDir, nil, WithJsonnetBundler("vendor"))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	if want := []string{".", "vendor"}; !slices.Equal(imp.JPaths, want) {
+		t.Errorf("JPaths = %v, want %v", imp.JPaths, want)
+	}
+}
+
+func TestWithJsonnetBundler_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		jsonnetfile string
+		setup       func(t *testing.T, root string)
+		vendorDir   string
+		wantErr     error
+	}{
+		{
+			name:    "missing jsonnetfile",
+			wantErr: ErrJsonnetfile,
+		},
+		{
+			name:        "malformed jsonnetfile",
+			jsonnetfile: `{"dependencies": [`,
+			wantErr:     ErrJsonnetfile,
+		},
+		{
+			name:        "vendor outside root",
+			jsonnetfile: `{"dependencies": []}`,
+			vendorDir:   "../vendor",
+			wantErr:     ErrJPathOutsideRoot,
+		},
+		{
+			name: "subdir traversal",
+			jsonnetfile: `{"dependencies": [
+  {"source": {"git": {"remote": "https://github.com/org/lib.git", "subdir": "../../../../etc"}}}
+]}`,
+			wantErr: ErrDependencyOutsideRoot,
+		},
+		{
+			name:        "local source outside root",
+			jsonnetfile: `{"dependencies": [{"source": {"local": {"directory": "../elsewhere"}}}]}`,
+			wantErr:     ErrDependencyOutsideRoot,
+		},
+		{
+			name: "legacy symlink outside root",
+			jsonnetfile: `{"dependencies": [
+  {"source": {"git": {"remote": "https://github.com/org/lib.git", "subdir": ""}}}
+]}`,
+			setup: func(t *testing.T, root string) {
+				t.Helper()
+
+				mustWriteFile(t, filepath.Join(root, "vendor", "github.com", "org", "lib", "main.libsonnet"), `{}`)
+				if err := os.Symlink(t.TempDir(), filepath.Join(root, "vendor", "lib")); err != nil {
+					t.Skipf("symlinks not supported: %v", err)
+				}
+			},
+			wantErr: ErrDependencyOutsideRoot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			if tt.jsonnetfile != "" {
+				mustWriteFile(t, filepath.Join(tmpDir, "jsonnetfile.json"), tt.jsonnetfile)
+			}
+			if tt.setup != nil {
+				tt.setup(t, tmpDir)
+			}
+
+			imp, err := NewSafeImporter(tmpDir, nil, WithJsonnetBundler(tt.vendorDir))
+			if err == nil {
+				imp.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewSafeImporter() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
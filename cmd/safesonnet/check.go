@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+type checkConfig struct {
+	importerFlags
+
+	file string
+}
+
+func parseCheckFlags(args []string, stderr io.Writer) (*checkConfig, error) {
+	cfg := &checkConfig{}
+	fs := flag.NewFlagSet("safesonnet check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: safesonnet check [flags] file")
+		fmt.Fprintln(fs.Output(), "Reports the imports in file and the files it imports that would be rejected,\n"+
+			"without evaluating them.")
+		fs.PrintDefaults()
+	}
+	cfg.register(fs)
+
+	var err error
+	cfg.file, err = parseFile(fs, args)
+
+	return cfg, err
+}
+
+// checkError summarizes the imports rejected by check. It unwraps to the first
+// sandbox violation, if any, so that violations decide the exit code.
+type checkError struct {
+	rejected  int
+	violation error
+}
+
+func (e *checkError) Error() string {
+	return fmt.Sprintf("%d import(s) rejected", e.rejected)
+}
+
+func (e *checkError) Unwrap() error {
+	return e.violation
+}
+
+// runCheck parses a file and the files it imports, without evaluating them,
+// and prints every import, importstr or importbin expression that would be
+// rejected as a file:line:column diagnostic.
+func runCheck(args []string, stdout, stderr io.Writer) error {
+	cfg, err := parseCheckFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	imp, err := safesonnet.NewSafeImporter(cfg.root, cfg.jpaths)
+	if err != nil {
+		return err
+	}
+	defer imp.Close()
+
+	res, err := safesonnet.ScanImports(imp, cfg.file)
+	if err != nil {
+		return err
+	}
+
+	result := &checkError{}
+	for _, si := range res.Imports {
+		if si.Err != nil {
+			fmt.Fprintf(stdout, "%s:%d:%d: %v\n", si.From, si.Line, si.Column, si.Err)
+			result.rejected++
+		}
+	}
+	if len(res.Violations) > 0 {
+		result.violation = res.Violations[0].Err
+	}
+	if result.rejected > 0 {
+		return result
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "a.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "ok.jsonnet"),
+		`{ a: import 'lib/a.libsonnet', b: importstr 'lib/a.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "bad.jsonnet"), `local a = import 'lib/a.libsonnet';
+local secret = importstr '../secret.txt';
+{
+  passwd: importbin '/etc/passwd',
+  typo: import 'lib/A.libsonnet',
+  // Not evaluated, but still checked.
+  unused:: if false then import 'missing.libsonnet' else null,
+}
+`)
+	mustWriteFile(t, filepath.Join(tmpDir, "missing.jsonnet"), `import 'missing.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "syntax.jsonnet"), `{ a: `)
+	mustWriteFile(t, filepath.Join(tmpDir, "nested.jsonnet"), `import 'lib/escape.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "escape.libsonnet"), `importstr '../../secret.txt'`)
+
+	tests := []struct {
+		name       string
+		file       string
+		wantCode   int
+		wantStdout []string
+		wantStderr string
+	}{
+		{
+			name: "clean",
+			file: "ok.jsonnet",
+		},
+		{
+			name:     "violations",
+			file:     "bad.jsonnet",
+			wantCode: exitViolation,
+			wantStdout: []string{
+				"bad.jsonnet:2:16: ", "forbidden relative import path traversal",
+				"bad.jsonnet:4:11: ", "forbidden absolute import path",
+				"bad.jsonnet:5:9: ", `did you mean "lib/a.libsonnet"?`,
+				"bad.jsonnet:7:26: ", "file not found",
+			},
+			wantStderr: "4 import(s) rejected",
+		},
+		{
+			name:     "nested",
+			file:     "nested.jsonnet",
+			wantCode: exitViolation,
+			wantStdout: []string{
+				filepath.Join("lib", "escape.libsonnet") + ":1:1: ", "forbidden relative import path traversal",
+			},
+			wantStderr: "1 import(s) rejected",
+		},
+		{
+			name:       "not found only",
+			file:       "missing.jsonnet",
+			wantCode:   exitError,
+			wantStdout: []string{"missing.jsonnet:1:1: ", "file not found"},
+			wantStderr: "1 import(s) rejected",
+		},
+		{
+			name:       "syntax error",
+			file:       "syntax.jsonnet",
+			wantCode:   exitError,
+			wantStderr: "syntax.jsonnet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"check", "--root", tmpDir, filepath.Join(tmpDir, tt.file)}, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d; stdout: %s; stderr: %s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+
+			// Diagnostics must appear in order.
+			out := stdout.String()
+			for _, want := range tt.wantStdout {
+				i := strings.Index(out, want)
+				if i < 0 {
+					t.Fatalf("stdout = %q, want it to contain %q in order", stdout.String(), want)
+				}
+				out = out[i+len(want):]
+			}
+			if len(tt.wantStdout) == 0 && stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want empty", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+// Output formats of the deps command.
+const (
+	depsFormatText = "text"
+	depsFormatJSON = "json"
+	depsFormatMake = "make"
+)
+
+type depsConfig struct {
+	vmFlags
+
+	file   string
+	format string
+	target string
+	abs    bool
+	output string
+}
+
+func parseDepsFlags(args []string, stderr io.Writer) (*depsConfig, error) {
+	cfg := &depsConfig{}
+	fs := flag.NewFlagSet("safesonnet deps", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: safesonnet deps [flags] file")
+		fmt.Fprintln(fs.Output(), "Evaluates file and lists every file it imported, including itself.")
+		fs.PrintDefaults()
+	}
+	cfg.register(fs)
+	fs.StringVar(&cfg.format, "format", depsFormatText, "output `format`: text, json or make")
+	fs.StringVar(&cfg.target, "target", "", "target of the make rule, required with -format make")
+	fs.BoolVar(&cfg.abs, "abs", false, "print absolute paths instead of root-relative ones (text and make formats)")
+	fs.StringVar(&cfg.output, "o", "", "write to `file` instead of stdout")
+	fs.StringVar(&cfg.output, "output-file", "", "same as -o")
+
+	var err error
+	if cfg.file, err = parseFile(fs, args); err != nil {
+		return nil, err
+	}
+
+	switch cfg.format {
+	case depsFormatText:
+	case depsFormatJSON:
+		if cfg.abs {
+			return nil, fmt.Errorf("%w: -abs is not supported with -format json", errUsage)
+		}
+	case depsFormatMake:
+		if cfg.target == "" {
+			return nil, fmt.Errorf("%w: -target is required with -format make", errUsage)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", errUsage, cfg.format)
+	}
+
+	return cfg, cfg.parse()
+}
+
+// runDeps evaluates a file, discarding its output, and lists the files it
+// imported as recorded by an ImportGraph.
+func runDeps(args []string, stdout, stderr io.Writer) error {
+	cfg, err := parseDepsFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	graph := safesonnet.NewImportGraph()
+	imp, err := safesonnet.NewSafeImporter(cfg.root, cfg.jpaths, safesonnet.WithImportGraph(graph))
+	if err != nil {
+		return err
+	}
+	defer imp.Close()
+
+	if err := loadEntrypoint(imp, cfg.file); err != nil {
+		return err
+	}
+	vm, rec := cfg.newVM(imp)
+	if _, err := vm.EvaluateFile(cfg.file); err != nil {
+		return rec.Wrap(err)
+	}
+
+	var b strings.Builder
+	if err := cfg.writeDeps(&b, graph); err != nil {
+		return err
+	}
+
+	return writeOutput(cfg.output, b.String(), stdout)
+}
+
+func (cfg *depsConfig) writeDeps(w io.Writer, graph *safesonnet.ImportGraph) error {
+	files := graph.Files()
+	if cfg.abs {
+		for i, f := range files {
+			if !filepath.IsAbs(f) {
+				files[i] = filepath.Join(graph.Root(), f)
+			}
+		}
+	}
+
+	switch cfg.format {
+	case depsFormatJSON:
+		return graph.WriteJSON(w)
+	case depsFormatMake:
+		return safesonnet.WriteDepfile(w, cfg.target, files)
+	default:
+		for _, f := range files {
+			if _, err := fmt.Fprintln(w, f); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunDeps(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`{ lib: import 'lib.libsonnet', text: importstr 'my data.txt' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "lib.libsonnet"), `import 'helper.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "helper.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "my data.txt"), `hello`)
+	mustWriteFile(t, filepath.Join(tmpDir, "escape.jsonnet"), `import '../outside.jsonnet'`)
+
+	main := filepath.Join(tmpDir, "main.jsonnet")
+	files := []string{
+		"main.jsonnet",
+		"my data.txt",
+		filepath.Join("vendor", "helper.libsonnet"),
+		filepath.Join("vendor", "lib.libsonnet"),
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "text",
+			args:       []string{"--root", tmpDir, "-J", "vendor", main},
+			wantStdout: strings.Join(files, "\n") + "\n",
+		},
+		{
+			name: "absolute",
+			args: []string{"--root", tmpDir, "-J", "vendor", "-abs", main},
+			wantStdout: filepath.Join(tmpDir, files[0]) + "\n" + filepath.Join(tmpDir, files[1]) + "\n" +
+				filepath.Join(tmpDir, files[2]) + "\n" + filepath.Join(tmpDir, files[3]) + "\n",
+		},
+		{
+			name: "make",
+			args: []string{"--root", tmpDir, "-J", "vendor", "-format", "make", "-target", "out.json", main},
+			wantStdout: "out.json: \\\n  main.jsonnet \\\n  my\\ data.txt \\\n  " + files[2] + " \\\n  " +
+				files[3] + "\n",
+		},
+		{
+			name:       "make without target",
+			args:       []string{"--root", tmpDir, "-format", "make", main},
+			wantCode:   exitUsage,
+			wantStderr: "-target is required",
+		},
+		{
+			name:       "absolute json",
+			args:       []string{"--root", tmpDir, "-abs", "-format", "json", main},
+			wantCode:   exitUsage,
+			wantStderr: "-abs is not supported",
+		},
+		{
+			name:       "unknown format",
+			args:       []string{"--root", tmpDir, "-format", "xml", main},
+			wantCode:   exitUsage,
+			wantStderr: "unknown format",
+		},
+		{
+			name:       "sandbox violation",
+			args:       []string{"--root", tmpDir, filepath.Join(tmpDir, "escape.jsonnet")},
+			wantCode:   exitViolation,
+			wantStderr: "forbidden relative import path traversal",
+		},
+		{
+			name:       "missing entrypoint",
+			args:       []string{"--root", tmpDir, filepath.Join(tmpDir, "nope.jsonnet")},
+			wantCode:   exitError,
+			wantStderr: "file not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(append([]string{"deps"}, tt.args...), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if strings.Contains(stderr.String(), "INTERNAL ERROR") {
+				t.Errorf("stderr = %q, reports an internal error", stderr.String())
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"deps", "--root", tmpDir, "-J", "vendor", "-format", "json", main}, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+		}
+
+		var doc struct {
+			Files []string `json:"files"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if !slices.Equal(doc.Files, files) {
+			t.Errorf("files = %v, want %v", doc.Files, files)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+type evalConfig struct {
+	vmFlags
+
+	file   string
+	multi  string
+	stream bool
+	output string
+}
+
+func parseEvalFlags(args []string, stderr io.Writer) (*evalConfig, error) {
+	cfg := &evalConfig{}
+	fs := flag.NewFlagSet("safesonnet", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: safesonnet [flags] file")
+		fmt.Fprintln(fs.Output(), "       safesonnet deps [flags] file")
+		fmt.Fprintln(fs.Output(), "       safesonnet check [flags] file")
+		fmt.Fprintln(fs.Output(), "       safesonnet vendor [flags] -o dest file")
+		fs.PrintDefaults()
+	}
+	cfg.register(fs)
+	fs.StringVar(&cfg.multi, "m", "", "write multiple files to `dir`, one per key of the output object")
+	fs.StringVar(&cfg.multi, "multi", "", "same as -m")
+	fs.BoolVar(&cfg.stream, "y", false, "write the output array as a YAML stream of JSON documents")
+	fs.BoolVar(&cfg.stream, "yaml-stream", false, "same as -y")
+	fs.StringVar(&cfg.output, "o", "", "write to `file` instead of stdout")
+	fs.StringVar(&cfg.output, "output-file", "", "same as -o")
+
+	var err error
+	if cfg.file, err = parseFile(fs, args); err != nil {
+		return nil, err
+	}
+	if cfg.multi != "" && cfg.stream {
+		return nil, fmt.Errorf("%w: -m and -y cannot be combined", errUsage)
+	}
+
+	return cfg, cfg.parse()
+}
+
+// runEval evaluates a file, like the jsonnet command.
+func runEval(args []string, stdout, stderr io.Writer) error {
+	cfg, err := parseEvalFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	imp, err := safesonnet.NewSafeImporter(cfg.root, cfg.jpaths)
+	if err != nil {
+		return err
+	}
+	defer imp.Close()
+
+	if err := loadEntrypoint(imp, cfg.file); err != nil {
+		return err
+	}
+	vm, rec := cfg.newVM(imp)
+
+	return cfg.write(vm, rec, stdout)
+}
+
+func (cfg *evalConfig) write(vm *jsonnet.VM, rec *safesonnet.ImportRecorder, stdout io.Writer) error {
+	switch {
+	case cfg.multi != "":
+		outputs, err := vm.EvaluateFileMulti(cfg.file)
+		if err != nil {
+			return rec.Wrap(err)
+		}
+
+		return writeMulti(cfg.multi, outputs, cfg.output, stdout)
+	case cfg.stream:
+		docs, err := vm.EvaluateFileStream(cfg.file)
+		if err != nil {
+			return rec.Wrap(err)
+		}
+
+		var b strings.Builder
+		for _, doc := range docs {
+			b.WriteString("---\n")
+			b.WriteString(doc)
+		}
+		if len(docs) > 0 {
+			b.WriteString("...\n")
+		}
+
+		return writeOutput(cfg.output, b.String(), stdout)
+	default:
+		out, err := vm.EvaluateFile(cfg.file)
+		if err != nil {
+			return rec.Wrap(err)
+		}
+
+		return writeOutput(cfg.output, out, stdout)
+	}
+}
+
+// writeMulti writes outputs inside dir and lists the files written.
+func writeMulti(dir string, outputs map[string]string, output string, stdout io.Writer) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	w, err := safesonnet.NewOutputWriter(dir)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	paths, err := w.WriteMulti(outputs)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, p := range paths {
+		b.WriteString(filepath.Join(dir, p))
+		b.WriteString("\n")
+	}
+
+	return writeOutput(output, b.String(), stdout)
+}
@@ -0,0 +1,227 @@
+// Command safesonnet evaluates Jsonnet files like the jsonnet command, with
+// every import confined to a root directory by safesonnet.SafeImporter.
+//
+// Usage:
+//
+//	safesonnet [flags] file
+//	safesonnet deps [flags] file
+//	safesonnet check [flags] file
+//	safesonnet vendor [flags] -o dest file
+//
+// Exit status is 0 on success, 1 when evaluation fails, 2 for usage errors
+// and 3 when evaluation was stopped by a sandbox violation, such as an import
+// outside the root directory.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+// Exit codes.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitViolation = 3
+)
+
+var (
+	errUsage = errors.New("usage error")
+	// errFlags marks usage errors that the flag package has already reported.
+	errFlags = errors.New("invalid flags")
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// command runs a subcommand with its arguments.
+type command func(args []string, stdout, stderr io.Writer) error
+
+func lookupCommand(name string) (command, bool) {
+	switch name {
+	case "deps":
+		return runDeps, true
+	case "check":
+		return runCheck, true
+	case "vendor":
+		return runVendor, true
+	default:
+		return nil, false
+	}
+}
+
+// run executes the command with args, excluding the program name, and returns
+// the exit code. Without a subcommand it evaluates a file.
+func run(args []string, stdout, stderr io.Writer) int {
+	cmd := command(runEval)
+	if len(args) > 0 {
+		if c, ok := lookupCommand(args[0]); ok {
+			cmd, args = c, args[1:]
+		}
+	}
+
+	return exitCode(cmd(args, stdout, stderr), stderr)
+}
+
+// exitCode reports err on stderr and maps it to an exit code.
+func exitCode(err error, stderr io.Writer) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errFlags):
+		return exitUsage
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+
+		return exitUsage
+	}
+
+	fmt.Fprintln(stderr, strings.TrimRight(err.Error(), "\n"))
+	if safesonnet.IsDenied(err) {
+		return exitViolation
+	}
+
+	return exitError
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+
+	return nil
+}
+
+// importerFlags are the flags shared by commands that resolve imports.
+type importerFlags struct {
+	root   string
+	jpaths stringList
+}
+
+func (f *importerFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.root, "root", ".", "directory that all imports must stay within")
+	fs.Var(&f.jpaths, "J", "library search `dir` inside the root, relative to the root (repeatable)")
+	fs.Var(&f.jpaths, "jpath", "same as -J")
+}
+
+// vmFlags are the flags shared by commands that evaluate Jsonnet.
+type vmFlags struct {
+	importerFlags
+
+	extStrs stringList
+	tlaStrs stringList
+	extVars map[string]string
+	tlaVars map[string]string
+}
+
+func (f *vmFlags) register(fs *flag.FlagSet) {
+	f.importerFlags.register(fs)
+	fs.Var(&f.extStrs, "ext-str", "external variable as `var[=value]`; without a value it is read from "+
+		"the environment (repeatable)")
+	fs.Var(&f.tlaStrs, "tla-str", "top-level argument as `var[=value]`; without a value it is read from "+
+		"the environment (repeatable)")
+}
+
+// parse finishes parsing after the flag set has been parsed.
+func (f *vmFlags) parse() error {
+	var err error
+	if f.extVars, err = parseVars(f.extStrs); err != nil {
+		return err
+	}
+	f.tlaVars, err = parseVars(f.tlaStrs)
+
+	return err
+}
+
+// newVM returns a VM importing through imp and the recorder of its failed
+// imports.
+func (f *vmFlags) newVM(imp *safesonnet.SafeImporter) (*jsonnet.VM, *safesonnet.ImportRecorder) {
+	rec := safesonnet.NewImportRecorder(imp)
+	vm := safesonnet.NewVM(imp, safesonnet.VMOptions{ExtVars: f.extVars, TLAVars: f.tlaVars})
+	vm.Importer(rec)
+
+	return vm, rec
+}
+
+// loadEntrypoint imports file through imp ahead of evaluation. go-jsonnet
+// reports a failure to import the entrypoint as an internal error in itself,
+// so the import error is returned as is instead. The importer caches the file
+// for the evaluation that follows.
+func loadEntrypoint(imp *safesonnet.SafeImporter, file string) error {
+	_, _, err := imp.Import("", file)
+
+	return err
+}
+
+// parseFile parses args, allowing flags after the file as the jsonnet command
+// does, and returns the single file argument.
+func parseFile(fs *flag.FlagSet, args []string) (string, error) {
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return "", err
+			}
+
+			return "", fmt.Errorf("%w: %w", errFlags, err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(files) != 1 {
+		fs.Usage()
+
+		return "", fmt.Errorf("%w: expected exactly one file, got %d", errUsage, len(files))
+	}
+
+	return files[0], nil
+}
+
+// writeOutput writes s to the file named by output, or to stdout when output
+// is empty. The output path comes from the command line, not from evaluated
+// code, so it is not confined to the root.
+func writeOutput(output, s string, stdout io.Writer) error {
+	if output == "" {
+		_, err := io.WriteString(stdout, s)
+
+		return err
+	}
+
+	return os.WriteFile(output, []byte(s), safesonnet.DefaultOutputFileMode)
+}
+
+// parseVars parses var[=value] flags. A variable without a value is read from
+// the environment, as the jsonnet command does.
+func parseVars(vars []string) (map[string]string, error) {
+	m := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			value, ok = os.LookupEnv(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: environment variable %q is not set", errUsage, name)
+			}
+		}
+		m[name] = value
+	}
+
+	return m, nil
+}
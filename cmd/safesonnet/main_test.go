@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`function(name) { name: name, env: std.extVar('env'), lib: import 'lib.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "lib.libsonnet"), `'from vendor'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "escape.jsonnet"), `import '../outside.jsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "fail.jsonnet"), `error 'boom'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "multi.jsonnet"), `{ 'a.json': 1, 'dir/b.json': 2 }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "badmulti.jsonnet"), `{ '../escape.json': 1 }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "stream.jsonnet"), `[1, 2]`)
+
+	main := filepath.Join(tmpDir, "main.jsonnet")
+	outDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.jsonnet")
+	mustWriteFile(t, outside, `{}`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name: "evaluate",
+			args: []string{
+				"--root", tmpDir, "-J", "vendor", "--ext-str", "env=prod", "--tla-str", "name=demo", main,
+			},
+			wantStdout: "{\n   \"env\": \"prod\",\n   \"lib\": \"from vendor\",\n   \"name\": \"demo\"\n}\n",
+		},
+		{
+			name: "flags after file",
+			args: []string{
+				"--root", tmpDir, main, "--jpath", "vendor", "--ext-str", "env=dev", "--tla-str", "name=x",
+			},
+			wantStdout: "{\n   \"env\": \"dev\",\n   \"lib\": \"from vendor\",\n   \"name\": \"x\"\n}\n",
+		},
+		{
+			name:       "stream",
+			args:       []string{"--root", tmpDir, "-y", filepath.Join(tmpDir, "stream.jsonnet")},
+			wantStdout: "---\n1\n---\n2\n...\n",
+		},
+		{
+			name:       "multi",
+			args:       []string{"--root", tmpDir, "-m", outDir, filepath.Join(tmpDir, "multi.jsonnet")},
+			wantStdout: filepath.Join(outDir, "a.json") + "\n" + filepath.Join(outDir, "dir", "b.json") + "\n",
+		},
+		{
+			name:       "import traversal",
+			args:       []string{"--root", tmpDir, filepath.Join(tmpDir, "escape.jsonnet")},
+			wantCode:   exitViolation,
+			wantStderr: "forbidden relative import path traversal",
+		},
+		{
+			name:       "multi output traversal",
+			args:       []string{"--root", tmpDir, "-m", t.TempDir(), filepath.Join(tmpDir, "badmulti.jsonnet")},
+			wantCode:   exitViolation,
+			wantStderr: "forbidden relative import path traversal",
+		},
+		{
+			name:       "missing entrypoint",
+			args:       []string{"--root", tmpDir, filepath.Join(tmpDir, "nope.jsonnet")},
+			wantCode:   exitError,
+			wantStderr: "file not found",
+		},
+		{
+			name:       "entrypoint outside root",
+			args:       []string{"--root", tmpDir, outside},
+			wantCode:   exitViolation,
+			wantStderr: "forbidden",
+		},
+		{
+			name:       "runtime error",
+			args:       []string{"--root", tmpDir, filepath.Join(tmpDir, "fail.jsonnet")},
+			wantCode:   exitError,
+			wantStderr: "boom",
+		},
+		{
+			name:       "missing file argument",
+			args:       []string{"--root", tmpDir},
+			wantCode:   exitUsage,
+			wantStderr: "expected exactly one file",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"--no-such-flag", main},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "unset environment variable",
+			args:       []string{"--root", tmpDir, "--ext-str", "SAFESONNET_TEST_UNSET", main},
+			wantCode:   exitUsage,
+			wantStderr: "SAFESONNET_TEST_UNSET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if strings.Contains(stderr.String(), "INTERNAL ERROR") {
+				t.Errorf("stderr = %q, reports an internal error", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_OutputFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{ a: 1 }`)
+	output := filepath.Join(t.TempDir(), "out.json")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--root", tmpDir, "-o", output, filepath.Join(tmpDir, "main.jsonnet")}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "{\n   \"a\": 1\n}\n"; string(got) != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want empty", stdout.String())
+	}
+}
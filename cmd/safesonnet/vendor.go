@@ -0,0 +1,108 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+type vendorConfig struct {
+	importerFlags
+
+	file   string
+	output string
+}
+
+func parseVendorFlags(args []string, stderr io.Writer) (*vendorConfig, error) {
+	cfg := &vendorConfig{}
+	fs := flag.NewFlagSet("safesonnet vendor", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: safesonnet vendor [flags] -o dest file")
+		fmt.Fprintln(fs.Output(), "Copies file and every file it imports into dest, rewriting imports so that\n"+
+			"dest evaluates identically as a root with no library paths.")
+		fs.PrintDefaults()
+	}
+	cfg.register(fs)
+	fs.StringVar(&cfg.output, "o", "", "destination directory, or a tarball if it ends in .tar, .tar.gz or .tgz")
+	fs.StringVar(&cfg.output, "output", "", "same as -o")
+
+	var err error
+	if cfg.file, err = parseFile(fs, args); err != nil {
+		return nil, err
+	}
+	if cfg.output == "" {
+		return nil, fmt.Errorf("%w: -o is required", errUsage)
+	}
+
+	return cfg, nil
+}
+
+// runVendor writes a self-contained copy of a file and its imports.
+func runVendor(args []string, _, stderr io.Writer) error {
+	cfg, err := parseVendorFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	imp, err := safesonnet.NewSafeImporter(cfg.root, cfg.jpaths)
+	if err != nil {
+		return err
+	}
+	defer imp.Close()
+
+	b, err := safesonnet.Vendor(imp, cfg.file)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasSuffix(cfg.output, ".tar"):
+		return writeTarball(cfg.output, b, false)
+	case strings.HasSuffix(cfg.output, ".tar.gz"), strings.HasSuffix(cfg.output, ".tgz"):
+		return writeTarball(cfg.output, b, true)
+	default:
+		return writeVendorDir(cfg.output, b)
+	}
+}
+
+func writeVendorDir(dir string, b *safesonnet.Bundle) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	w, err := safesonnet.NewOutputWriter(dir)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return b.WriteDir(w)
+}
+
+func writeTarball(path string, b *safesonnet.Bundle, compress bool) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	if !compress {
+		return b.WriteTar(f)
+	}
+
+	zw := gzip.NewWriter(f)
+	if err := b.WriteTar(zw); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunVendor(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{ lib: import 'lib.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "lib.libsonnet"), `{ name: 'lib' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "escape.jsonnet"), `import '../outside.jsonnet'`)
+	main := filepath.Join(tmpDir, "main.jsonnet")
+
+	t.Run("directory", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := filepath.Join(t.TempDir(), "out")
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"vendor", "--root", tmpDir, "-J", "vendor", "-o", outDir, main}, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+		}
+
+		stdout.Reset()
+		if code := run([]string{"--root", outDir, filepath.Join(outDir, "main.jsonnet")}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("run() on the vendored tree = %d; stderr: %s", code, stderr.String())
+		}
+		if want := "{\n   \"lib\": {\n      \"name\": \"lib\"\n   }\n}\n"; stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	})
+
+	t.Run("tarball", func(t *testing.T) {
+		t.Parallel()
+
+		archive := filepath.Join(t.TempDir(), "bundle.tgz")
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"vendor", "--root", tmpDir, "-J", "vendor", "-o", archive, main}, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+		}
+
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error = %v", err)
+		}
+
+		var names []string
+		tr := tar.NewReader(zr)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, hdr.Name)
+		}
+		if want := []string{"main.jsonnet", "vendor/lib.libsonnet"}; !slices.Equal(names, want) {
+			t.Errorf("tarball entries = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("sandbox violation", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := t.TempDir()
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"vendor", "--root", tmpDir, "-o", outDir, filepath.Join(tmpDir, "escape.jsonnet")},
+			&stdout, &stderr)
+		if code != exitViolation {
+			t.Fatalf("run() = %d, want %d; stderr: %s", code, exitViolation, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "forbidden relative import path traversal") {
+			t.Errorf("stderr = %q, want the traversal", stderr.String())
+		}
+	})
+
+	t.Run("missing output", func(t *testing.T) {
+		t.Parallel()
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"vendor", "--root", tmpDir, main}, &stdout, &stderr); code != exitUsage {
+			t.Fatalf("run() = %d, want %d", code, exitUsage)
+		}
+	})
+}
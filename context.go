@@ -0,0 +1,31 @@
+package safesonnet
+
+import (
+	"context"
+
+	"github.com/google/go-jsonnet"
+)
+
+// ContextImporter is a view of a SafeImporter bound to a context, typically
+// for a single evaluation. It shares the root, options and cache of the
+// importer it was created from. Once the context is done, pending file reads
+// and library path searches stop and Import returns an *ImportError wrapping
+// the context error, or its cause when one was set. Abandoned reads release
+// their goroutines and files as described for WithReadTimeout.
+type ContextImporter struct {
+	s   *SafeImporter
+	ctx context.Context //nolint:containedctx // jsonnet.Importer has no context parameter.
+}
+
+var _ jsonnet.Importer = (*ContextImporter)(nil)
+
+// WithContext returns a view of s whose imports observe ctx. See
+// ContextImporter for what happens to reads pending when ctx is done.
+func (s *SafeImporter) WithContext(ctx context.Context) *ContextImporter {
+	return &ContextImporter{s: s, ctx: ctx}
+}
+
+// Import implements jsonnet.Importer.
+func (c *ContextImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	return c.s.importContext(c.ctx, importedFrom, importedPath)
+}
@@ -0,0 +1,42 @@
+package safesonnet
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+)
+
+func TestContextImporter(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `import 'lib.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib.libsonnet"), `{ok: true}`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(imp.WithContext(t.Context()))
+	if _, err := vm.EvaluateFile(filepath.Join(tmpDir, "main.jsonnet")); err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, _, err = imp.WithContext(ctx).Import("", filepath.Join(tmpDir, "other.jsonnet"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Import() error = %v, want context.Canceled", err)
+	}
+	var ie *ImportError
+	if !errors.As(err, &ie) {
+		t.Errorf("Import() error %T is not an *ImportError", err)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package safesonnet
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPendingRead_Abandon(t *testing.T) {
+	t.Parallel()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	defer w.Close()
+
+	// A pipe with an idle writer stands in for a read that never completes.
+	pending := &pendingRead{}
+	if !pending.opened(r) {
+		t.Fatal("opened() = false before abandon()")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(r)
+		done <- err
+	}()
+
+	pending.abandon()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("abandoned read returned no error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned read is still blocked")
+	}
+
+	r2, w2, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	defer r2.Close()
+	defer w2.Close()
+	if pending.opened(r2) {
+		t.Error("opened() = true after abandon()")
+	}
+}
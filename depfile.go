@@ -0,0 +1,70 @@
+package safesonnet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDepfile writes a Makefile dependency rule "target: deps..." to w, in
+// the format understood by make and ninja. Spaces, '#' and '$' in paths are
+// escaped; paths containing newlines cannot be represented and are rejected
+// with ErrDepfilePath.
+func WriteDepfile(w io.Writer, target string, deps []string) error {
+	escTarget, err := escapeDepfilePath(target)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(escTarget)
+	b.WriteString(":")
+	for _, dep := range deps {
+		escDep, err := escapeDepfilePath(dep)
+		if err != nil {
+			return err
+		}
+		b.WriteString(" \\\n  ")
+		b.WriteString(escDep)
+	}
+	b.WriteString("\n")
+
+	_, err = io.WriteString(w, b.String())
+
+	return err
+}
+
+// WriteDepfile writes a Makefile dependency rule listing every file in the
+// graph as a prerequisite of target. Paths are root-relative.
+func (g *ImportGraph) WriteDepfile(w io.Writer, target string) error {
+	return WriteDepfile(w, target, g.Files())
+}
+
+// escapeDepfilePath escapes path the way GCC does for -MD output, which both
+// make and ninja parse.
+func escapeDepfilePath(path string) (string, error) {
+	if strings.ContainsAny(path, "\n\r") {
+		return "", fmt.Errorf("%w: %q", ErrDepfilePath, path)
+	}
+
+	var b strings.Builder
+	for i := range len(path) {
+		switch c := path[i]; c {
+		case ' ', '\t':
+			// Backslashes preceding a space must themselves be escaped.
+			for j := i - 1; j >= 0 && path[j] == '\\'; j-- {
+				b.WriteByte('\\')
+			}
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '#':
+			b.WriteString(`\#`)
+		case '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,90 @@
+package safesonnet
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+)
+
+func TestWriteDepfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		target  string
+		deps    []string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "no deps",
+			target: "out.json",
+			want:   "out.json:\n",
+		},
+		{
+			name:   "plain paths",
+			target: "out.json",
+			deps:   []string{"main.jsonnet", "lib/a.libsonnet"},
+			want:   "out.json: \\\n  main.jsonnet \\\n  lib/a.libsonnet\n",
+		},
+		{
+			name:   "special characters",
+			target: "my out.json",
+			deps:   []string{"a b.jsonnet", "c#d$.jsonnet", `e\ f.jsonnet`},
+			want:   "my\\ out.json: \\\n  a\\ b.jsonnet \\\n  c\\#d$$.jsonnet \\\n  e\\\\\\ f.jsonnet\n",
+		},
+		{
+			name:    "newline rejected",
+			target:  "out.json",
+			deps:    []string{"bad\nname.jsonnet"},
+			wantErr: ErrDepfilePath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := WriteDepfile(&buf, tt.target, tt.deps)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteDepfile() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && buf.String() != tt.want {
+				t.Errorf("WriteDepfile() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestImportGraph_WriteDepfile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `import 'my lib.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "my lib.libsonnet"), `{}`)
+
+	graph := NewImportGraph()
+	imp, err := NewSafeImporter(tmpDir, nil, WithImportGraph(graph))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(imp)
+	if _, err := vm.EvaluateFile(filepath.Join(tmpDir, "main.jsonnet")); err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := graph.WriteDepfile(&buf, "out.json"); err != nil {
+		t.Fatalf("WriteDepfile() error = %v", err)
+	}
+	if want := "out.json: \\\n  main.jsonnet \\\n  my\\ lib.libsonnet\n"; buf.String() != want {
+		t.Errorf("WriteDepfile() = %q, want %q", buf.String(), want)
+	}
+}
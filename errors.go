@@ -0,0 +1,112 @@
+package safesonnet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Operations reported in ImportError.Op.
+const (
+	// OpValidate is input validation of the import paths.
+	OpValidate = "validate"
+	// OpResolve is resolution of the import path against the root.
+	OpResolve = "resolve"
+	// OpRead is opening, checking or reading a candidate file.
+	OpRead = "read"
+	// OpSearch is the search through library paths.
+	OpSearch = "search"
+	// OpHook is an import hook.
+	OpHook = "hook"
+)
+
+// ImportError describes a failed import. Err wraps one of the package
+// sentinels, so errors.Is keeps working while errors.As gives access to the
+// fields.
+type ImportError struct {
+	// Op is the operation that failed, one of the Op constants.
+	Op string
+	// ImportedFrom is the importing file, or empty for the entrypoint.
+	ImportedFrom string
+	// ImportedPath is the path as written in the import expression.
+	ImportedPath string
+	// ResolvedPath is the absolute candidate path involved, if any.
+	ResolvedPath string
+	// JPath is the library path the candidate was derived from, if any.
+	JPath string
+	// Candidates lists the paths searched when the file was not found.
+	Candidates []Candidate
+	// Suggestions lists similarly named import paths when the file was not found.
+	Suggestions []string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ImportError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Op)
+	b.WriteString(" ")
+	b.WriteString(strconv.Quote(e.ImportedPath))
+	if e.ImportedFrom != "" {
+		b.WriteString(" from ")
+		b.WriteString(strconv.Quote(e.ImportedFrom))
+	}
+	if e.ResolvedPath != "" {
+		b.WriteString(" (resolved to ")
+		b.WriteString(strconv.Quote(e.ResolvedPath))
+		if e.JPath != "" {
+			b.WriteString(" in jpath ")
+			b.WriteString(strconv.Quote(e.JPath))
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	if len(e.Candidates) > 0 {
+		b.WriteString(" (tried ")
+		for i, c := range e.Candidates {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			b.WriteString(c.String())
+		}
+		b.WriteString(")")
+	}
+	if len(e.Suggestions) > 0 {
+		b.WriteString("; did you mean ")
+		for i, sg := range e.Suggestions {
+			if i > 0 {
+				b.WriteString(" or ")
+			}
+			b.WriteString(strconv.Quote(sg))
+		}
+		b.WriteString("?")
+	}
+
+	return b.String()
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// IsDenied reports whether err is a sandbox or policy violation, such as a
+// path traversal or a file rejected by the file policy, rather than an
+// operational failure or a missing file.
+func IsDenied(err error) bool {
+	for _, sentinel := range []error{
+		ErrInvalidNullByte,
+		ErrForbiddenAbsolutePath,
+		ErrForbiddenRelativePathTraversal,
+		ErrForbiddenPathTraversal,
+		ErrWorldWritable,
+		ErrFileOwner,
+		ErrImportRejected,
+	} {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+
+	return false
+}
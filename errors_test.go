@@ -0,0 +1,117 @@
+package safesonnet
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportError(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	outsideDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "dir.libsonnet", "file"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	from := filepath.Join(tmpDir, "main.jsonnet")
+
+	tests := []struct {
+		name         string
+		importedFrom string
+		importedPath string
+		want         ImportError
+		wantErr      error
+	}{
+		{
+			name:         "null byte",
+			importedFrom: from,
+			importedPath: "a\x00b",
+			want:         ImportError{Op: OpValidate},
+			wantErr:      ErrInvalidNullByte,
+		},
+		{
+			name:         "absolute outside root",
+			importedFrom: from,
+			importedPath: filepath.Join(outsideDir, "x.jsonnet"),
+			want:         ImportError{Op: OpResolve, ResolvedPath: filepath.Join(outsideDir, "x.jsonnet")},
+			wantErr:      ErrForbiddenAbsolutePath,
+		},
+		{
+			name:         "relative traversal",
+			importedFrom: from,
+			importedPath: "../x.jsonnet",
+			want:         ImportError{Op: OpResolve, ResolvedPath: filepath.Join(filepath.Dir(tmpDir), "x.jsonnet")},
+			wantErr:      ErrForbiddenRelativePathTraversal,
+		},
+		{
+			name:         "read error in jpath",
+			importedFrom: from,
+			importedPath: "dir.libsonnet",
+			want: ImportError{
+				Op:           OpRead,
+				ResolvedPath: filepath.Join(tmpDir, "lib", "dir.libsonnet"),
+				JPath:        "lib",
+			},
+			wantErr: ErrReadFile,
+		},
+		{
+			name:         "not found",
+			importedFrom: from,
+			importedPath: "missing.jsonnet",
+			want:         ImportError{Op: OpSearch},
+			wantErr:      ErrFileNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := imp.Import(tt.importedFrom, tt.importedPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Import() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var ie *ImportError
+			if !errors.As(err, &ie) {
+				t.Fatalf("Import() error %T is not an *ImportError", err)
+			}
+			if ie.Op != tt.want.Op || ie.ResolvedPath != tt.want.ResolvedPath || ie.JPath != tt.want.JPath {
+				t.Errorf("ImportError = %+v, want %+v", ie, tt.want)
+			}
+			if ie.ImportedFrom != tt.importedFrom || ie.ImportedPath != tt.importedPath {
+				t.Errorf("ImportError paths = (%q, %q), want (%q, %q)",
+					ie.ImportedFrom, ie.ImportedPath, tt.importedFrom, tt.importedPath)
+			}
+		})
+	}
+}
+
+func TestIsDenied(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &ImportError{Op: OpResolve, Err: ErrForbiddenRelativePathTraversal}, want: true},
+		{err: fmt.Errorf("wrapped: %w", ErrWorldWritable), want: true},
+		{err: &ImportError{Op: OpSearch, Err: ErrFileNotFound}, want: false},
+		{err: ErrReadFile, want: false},
+		{err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDenied(tt.err); got != tt.want {
+			t.Errorf("IsDenied(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package safesonnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-jsonnet"
+)
+
+// LimitKind identifies a resource limit in Limits.
+type LimitKind string
+
+const (
+	// LimitTimeout is the wall-clock limit.
+	LimitTimeout LimitKind = "timeout"
+	// LimitOutput is the output size limit.
+	LimitOutput LimitKind = "output size"
+	// LimitImports is the import count limit.
+	LimitImports LimitKind = "import count"
+	// LimitStack is the stack depth limit.
+	LimitStack LimitKind = "stack depth"
+)
+
+// stackOverflowMessage is how go-jsonnet reports exceeding VM.MaxStack.
+const stackOverflowMessage = "max stack frames exceeded."
+
+// Limits bounds the resources used by EvaluateFile. Zero fields mean no
+// limit, except MaxStack, which falls back to DefaultMaxStack.
+type Limits struct {
+	// Timeout bounds the wall-clock time of the evaluation.
+	Timeout time.Duration
+	// MaxOutputBytes bounds the size of the JSON output.
+	MaxOutputBytes int
+	// MaxImports bounds the number of distinct files imported, including the
+	// entrypoint. Importing a file again does not count.
+	MaxImports int
+	// MaxStack bounds the evaluation stack depth.
+	MaxStack int
+}
+
+// Result is a successful evaluation.
+type Result struct {
+	// Output is the JSON output.
+	Output string
+	// Imports is the number of distinct files imported, including the entrypoint.
+	Imports int
+	// Duration is the wall-clock time of the evaluation.
+	Duration time.Duration
+}
+
+// LimitError reports that an evaluation exceeded one of its Limits.
+type LimitError struct {
+	// Kind is the limit that was exceeded.
+	Kind LimitKind
+	// Limit is the configured value: nanoseconds for LimitTimeout, bytes for
+	// LimitOutput, a count otherwise.
+	Limit int64
+}
+
+func (e *LimitError) Error() string {
+	limit := strconv.FormatInt(e.Limit, 10)
+	if e.Kind == LimitTimeout {
+		limit = time.Duration(e.Limit).String()
+	}
+
+	return fmt.Sprintf("%s: %s of %s", ErrLimitExceeded, e.Kind, limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// EvalError reports that Jsonnet evaluation failed. When the failure was
+// caused by an import, Import holds the importer's error, so errors.Is matches
+// the sandbox sentinels.
+type EvalError struct {
+	// Err is the error reported by go-jsonnet.
+	Err error
+	// Import is the last failed import, if any.
+	Import *ImportError
+}
+
+func (e *EvalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EvalError) Unwrap() []error {
+	if e.Import != nil {
+		return []error{e.Err, e.Import}
+	}
+
+	return []error{e.Err}
+}
+
+// EvaluateFile evaluates the file at path within limits, using a VM with the
+// NewVM defaults that imports through a view of s bound to ctx.
+// It returns an *ImportError when path cannot be imported, a *LimitError when
+// a limit is exceeded, an *EvalError when evaluation fails, or the context
+// error when ctx is done.
+//
+// go-jsonnet cannot interrupt a running evaluation, so after a timeout or
+// cancellation pending imports fail promptly but CPU-bound evaluation keeps
+// running in the background until it finishes.
+func (s *SafeImporter) EvaluateFile(ctx context.Context, path string, limits Limits) (*Result, error) {
+	start := time.Now()
+
+	evalCtx := ctx
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		evalCtx, cancel = context.WithTimeoutCause(ctx, limits.Timeout, &LimitError{
+			Kind:  LimitTimeout,
+			Limit: int64(limits.Timeout),
+		})
+		defer cancel()
+	}
+
+	limited := &limitedImporter{imp: s.WithContext(evalCtx), max: limits.MaxImports}
+	rec := NewImportRecorder(limited)
+	// go-jsonnet reports a failure to import the entrypoint as an internal
+	// error, so it is imported first and its error returned as is. The cached
+	// file is then used by the evaluation.
+	if _, _, err := rec.Import("", path); err != nil {
+		if evalCtx.Err() != nil {
+			return nil, context.Cause(evalCtx)
+		}
+
+		return nil, err
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(rec)
+	applyVMOptions(vm, VMOptions{MaxStack: limits.MaxStack})
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		// Evaluate the AST rather than calling EvaluateFile, which formats
+		// errors into strings, so that stack overflows can be recognized.
+		var res result
+		node, _, err := vm.ImportAST("", path)
+		if err == nil {
+			res.out, err = vm.Evaluate(node)
+		}
+		res.err = err
+		done <- res
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-evalCtx.Done():
+		return nil, context.Cause(evalCtx)
+	}
+
+	if res.err != nil {
+		return nil, evalError(vm, rec, res.err)
+	}
+	if limits.MaxOutputBytes > 0 && len(res.out) > limits.MaxOutputBytes {
+		return nil, &LimitError{Kind: LimitOutput, Limit: int64(limits.MaxOutputBytes)}
+	}
+
+	return &Result{Output: res.out, Imports: limited.imports(), Duration: time.Since(start)}, nil
+}
+
+// ImportRecorder is a jsonnet.Importer that remembers the last failed import.
+// go-jsonnet reports importer errors as strings, so evaluation errors from a
+// VM importing through an ImportRecorder can be passed to Wrap to recover the
+// typed import error.
+type ImportRecorder struct {
+	imp jsonnet.Importer
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+var _ jsonnet.Importer = (*ImportRecorder)(nil)
+
+// NewImportRecorder returns an ImportRecorder importing through imp.
+func NewImportRecorder(imp jsonnet.Importer) *ImportRecorder {
+	return &ImportRecorder{imp: imp}
+}
+
+// Import implements jsonnet.Importer.
+func (r *ImportRecorder) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	contents, foundAt, err := r.imp.Import(importedFrom, importedPath)
+	if err != nil {
+		r.mu.Lock()
+		r.lastErr = err
+		r.mu.Unlock()
+	}
+
+	return contents, foundAt, err
+}
+
+// Err returns the error of the last failed import, or nil if none failed.
+func (r *ImportRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastErr
+}
+
+// Wrap returns err, an evaluation error from a VM importing through r, as an
+// *EvalError holding the last failed import. It returns nil if err is nil.
+func (r *ImportRecorder) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	evalErr := &EvalError{Err: err}
+	errors.As(r.Err(), &evalErr.Import)
+
+	return evalErr
+}
+
+// limitedImporter counts the distinct files imported.
+type limitedImporter struct {
+	imp *ContextImporter
+	max int
+
+	mu    sync.Mutex
+	files map[string]struct{}
+}
+
+// Import imports through l.imp. go-jsonnet calls Import each time an import
+// expression is evaluated, so only files not seen before count towards the
+// limit.
+func (l *limitedImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	contents, foundAt, err := l.imp.Import(importedFrom, importedPath)
+	if err != nil {
+		return contents, foundAt, err
+	}
+	if !l.count(foundAt) {
+		return jsonnet.Contents{}, "", &LimitError{Kind: LimitImports, Limit: int64(l.max)}
+	}
+
+	return contents, foundAt, nil
+}
+
+// count records foundAt and reports whether it is within the limit.
+func (l *limitedImporter) count(foundAt string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.files[foundAt]; ok {
+		return true
+	}
+	if l.max > 0 && len(l.files) >= l.max {
+		return false
+	}
+	if l.files == nil {
+		l.files = make(map[string]struct{})
+	}
+	l.files[foundAt] = struct{}{}
+
+	return true
+}
+
+func (l *limitedImporter) imports() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.files)
+}
+
+// evalError classifies an unformatted error returned by go-jsonnet. A stack
+// overflow is only reported as a limit when the stack trace shows the VM
+// really reached MaxStack, since Jsonnet code can raise an error with the
+// same message.
+func evalError(vm *jsonnet.VM, rec *ImportRecorder, err error) error {
+	var limitErr *LimitError
+	if errors.As(rec.Err(), &limitErr) {
+		return limitErr
+	}
+
+	var rtErr jsonnet.RuntimeError
+	if errors.As(err, &rtErr) && rtErr.Msg == stackOverflowMessage && len(rtErr.StackTrace) > vm.MaxStack {
+		return &LimitError{Kind: LimitStack, Limit: int64(vm.MaxStack)}
+	}
+
+	return rec.Wrap(errors.New(vm.ErrorFormatter.Format(err)))
+}
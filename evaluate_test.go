@@ -0,0 +1,202 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{ a: import 'a.libsonnet', b: import 'b.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "a.libsonnet"), `'a'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "b.libsonnet"), `'b'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "repeat.jsonnet"),
+		`local f(i) = (import 'a.libsonnet') + i; [f(i) for i in std.range(1, 10)] + [import 'b.libsonnet']`)
+	mustWriteFile(t, filepath.Join(tmpDir, "big.jsonnet"), `std.repeat(['x'], 1000)`)
+	mustWriteFile(t, filepath.Join(tmpDir, "recurse.jsonnet"), `local f(n) = if n == 0 then 0 else 1 + f(n - 1); f(100)`)
+	mustWriteFile(t, filepath.Join(tmpDir, "fail.jsonnet"), `error 'boom'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "fake.jsonnet"), `error 'max stack frames exceeded.'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "escape.jsonnet"), `import '../outside.jsonnet'`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	res, err := imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "main.jsonnet"), Limits{MaxImports: 3})
+	if err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+	if res.Imports != 3 {
+		t.Errorf("Result.Imports = %d, want 3", res.Imports)
+	}
+	if want := "{\n   \"a\": \"a\",\n   \"b\": \"b\"\n}\n"; res.Output != want {
+		t.Errorf("Result.Output = %q, want %q", res.Output, want)
+	}
+
+	// The import inside f is evaluated on every call but counts once.
+	res, err = imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "repeat.jsonnet"), Limits{MaxImports: 3})
+	if err != nil {
+		t.Fatalf("EvaluateFile() repeated import error = %v", err)
+	}
+	if res.Imports != 3 {
+		t.Errorf("Result.Imports with repeated import = %d, want 3", res.Imports)
+	}
+
+	tests := []struct {
+		name   string
+		file   string
+		limits Limits
+		want   LimitKind
+	}{
+		{name: "imports", file: "main.jsonnet", limits: Limits{MaxImports: 2}, want: LimitImports},
+		{name: "repeated imports", file: "repeat.jsonnet", limits: Limits{MaxImports: 2}, want: LimitImports},
+		{name: "output", file: "big.jsonnet", limits: Limits{MaxOutputBytes: 100}, want: LimitOutput},
+		{name: "stack", file: "recurse.jsonnet", limits: Limits{MaxStack: 10}, want: LimitStack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, tt.file), tt.limits)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Kind != tt.want {
+				t.Fatalf("EvaluateFile() error = %v, want %s limit", err, tt.want)
+			}
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("EvaluateFile() error = %v, want ErrLimitExceeded", err)
+			}
+		})
+	}
+
+	t.Run("runtime error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "fail.jsonnet"), Limits{})
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) {
+			t.Fatalf("EvaluateFile() error = %v, want *EvalError", err)
+		}
+		if evalErr.Import != nil {
+			t.Errorf("EvalError.Import = %v, want nil", evalErr.Import)
+		}
+		if errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("EvaluateFile() error = %v, should not be a limit violation", err)
+		}
+	})
+
+	t.Run("entrypoint", func(t *testing.T) {
+		t.Parallel()
+
+		outside := filepath.Join(t.TempDir(), "outside.jsonnet")
+		mustWriteFile(t, outside, `{}`)
+
+		for path, want := range map[string]error{
+			filepath.Join(tmpDir, "nope.jsonnet"): ErrFileNotFound,
+			outside:                               ErrForbiddenAbsolutePath,
+		} {
+			_, err := imp.EvaluateFile(t.Context(), path, Limits{})
+			var importErr *ImportError
+			if !errors.As(err, &importErr) || !errors.Is(err, want) {
+				t.Errorf("EvaluateFile(%q) error = %v, want *ImportError matching %v", path, err, want)
+			}
+			if err != nil && strings.Contains(err.Error(), "INTERNAL ERROR") {
+				t.Errorf("EvaluateFile(%q) error = %q, reports an internal error", path, err)
+			}
+		}
+	})
+
+	t.Run("faked stack overflow", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "fake.jsonnet"), Limits{})
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) || errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("EvaluateFile() error = %v, want *EvalError that is not a limit violation", err)
+		}
+		if !strings.Contains(err.Error(), "RUNTIME ERROR: max stack frames exceeded.") {
+			t.Errorf("EvaluateFile() error = %q, want the formatted runtime error", err)
+		}
+	})
+
+	t.Run("sandbox violation", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "escape.jsonnet"), Limits{})
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) || evalErr.Import == nil {
+			t.Fatalf("EvaluateFile() error = %v, want *EvalError with Import set", err)
+		}
+		if !errors.Is(err, ErrForbiddenRelativePathTraversal) {
+			t.Errorf("EvaluateFile() error = %v, want ErrForbiddenRelativePathTraversal", err)
+		}
+	})
+}
+
+func TestEvaluateFile_Timeout(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	// A long tail-recursive loop keeps the VM busy without growing the stack.
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`local loop(n) = if n == 0 then 0 else loop(n - 1) tailstrict; loop(1e9)`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	start := time.Now()
+	_, err = imp.EvaluateFile(t.Context(), filepath.Join(tmpDir, "main.jsonnet"), Limits{Timeout: 50 * time.Millisecond})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitTimeout {
+		t.Fatalf("EvaluateFile() error = %v, want timeout limit", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("EvaluateFile() took %v to time out", elapsed)
+	}
+}
+
+func TestImportRecorder(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `import 'missing.libsonnet'`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	rec := NewImportRecorder(imp)
+	if err := rec.Wrap(nil); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+
+	vm := NewVM(imp, VMOptions{})
+	vm.Importer(rec)
+	_, err = vm.EvaluateFile(filepath.Join(tmpDir, "main.jsonnet"))
+	if err == nil {
+		t.Fatal("EvaluateFile() error = nil, want missing import")
+	}
+
+	err = rec.Wrap(err)
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) || evalErr.Import == nil {
+		t.Fatalf("Wrap() = %v, want *EvalError with Import set", err)
+	}
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Wrap() = %v, want ErrFileNotFound", err)
+	}
+	if !errors.Is(rec.Err(), ErrFileNotFound) {
+		t.Errorf("Err() = %v, want ErrFileNotFound", rec.Err())
+	}
+}
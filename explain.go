@@ -0,0 +1,129 @@
+package safesonnet
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// CandidateOutcome is the result of considering a single candidate path.
+type CandidateOutcome string
+
+const (
+	// CandidateFound means the file exists and was read.
+	CandidateFound CandidateOutcome = "found"
+	// CandidateNotFound means no file exists at the candidate path.
+	CandidateNotFound CandidateOutcome = "not found"
+	// CandidateOutsideRoot means the candidate path is outside the root and
+	// was not probed.
+	CandidateOutsideRoot CandidateOutcome = "outside root"
+	// CandidateDenied means the file exists but was rejected by a policy.
+	CandidateDenied CandidateOutcome = "denied"
+	// CandidateReadError means the file could not be read.
+	CandidateReadError CandidateOutcome = "read error"
+)
+
+// Candidate is a path considered while resolving an import.
+type Candidate struct {
+	// Path is the absolute candidate path.
+	Path string
+	// JPath is the library path the candidate was derived from, or empty for
+	// the lookup relative to the importing file.
+	JPath string
+	// Outcome is the result of considering the candidate.
+	Outcome CandidateOutcome
+	// Cached reports whether the outcome came from the importer's cache.
+	Cached bool
+	// Err is the error behind a denied, read error or outside root outcome.
+	Err error
+}
+
+func (c Candidate) String() string {
+	var b strings.Builder
+	b.WriteString(c.Path)
+	b.WriteString(": ")
+	b.WriteString(string(c.Outcome))
+	if c.Cached {
+		b.WriteString(" (cached)")
+	}
+
+	return b.String()
+}
+
+// Explain resolves an import like Import and returns every candidate that was
+// considered, in order. The error is the one Import would return. Hooks are
+// not run.
+func (s *SafeImporter) Explain(importedFrom, importedPath string) ([]Candidate, error) {
+	r := newResolution(importedFrom, importedPath)
+	_, _, err := s.resolve(context.Background(), r)
+
+	return r.candidates, err
+}
+
+// resolution tracks a single import through resolution.
+type resolution struct {
+	importedFrom string
+	importedPath string
+	// statOnly locates files without reading them.
+	statOnly   bool
+	candidates []Candidate
+}
+
+func newResolution(importedFrom, importedPath string) *resolution {
+	return &resolution{importedFrom: importedFrom, importedPath: importedPath}
+}
+
+func (r *resolution) add(c Candidate) {
+	r.candidates = append(r.candidates, c)
+}
+
+// cached reports whether the file was found in the cache.
+func (r *resolution) cached() bool {
+	if len(r.candidates) == 0 {
+		return false
+	}
+	last := r.candidates[len(r.candidates)-1]
+
+	return last.Outcome == CandidateFound && last.Cached
+}
+
+// tried reports whether path has already been considered.
+func (r *resolution) tried(path string) bool {
+	return slices.ContainsFunc(r.candidates, func(c Candidate) bool { return c.Path == path })
+}
+
+// fail wraps err in an ImportError describing this resolution.
+func (r *resolution) fail(op, resolvedPath, jpath string, err error) *ImportError {
+	return &ImportError{
+		Op:           op,
+		ImportedFrom: r.importedFrom,
+		ImportedPath: r.importedPath,
+		ResolvedPath: resolvedPath,
+		JPath:        jpath,
+		Err:          err,
+	}
+}
+
+// notFound returns ErrFileNotFound listing the candidates searched.
+func (r *resolution) notFound() *ImportError {
+	err := r.fail(OpSearch, "", "", ErrFileNotFound)
+	err.Candidates = r.candidates
+
+	return err
+}
+
+func newCandidate(path, jpath string, res fileLookup, err error) Candidate {
+	c := Candidate{Path: path, JPath: jpath, Cached: res.cached, Err: err}
+	switch {
+	case err != nil && IsDenied(err):
+		c.Outcome = CandidateDenied
+	case err != nil:
+		c.Outcome = CandidateReadError
+	case res.found:
+		c.Outcome = CandidateFound
+	default:
+		c.Outcome = CandidateNotFound
+	}
+
+	return c
+}
@@ -0,0 +1,113 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "app", "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "util.libsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib", "vendor"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	from := filepath.Join(tmpDir, "app", "main.jsonnet")
+
+	candidates, err := imp.Explain(from, "util.libsonnet")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	want := []Candidate{
+		{Path: filepath.Join(tmpDir, "app", "util.libsonnet"), Outcome: CandidateNotFound},
+		{Path: filepath.Join(tmpDir, "lib", "util.libsonnet"), JPath: "lib", Outcome: CandidateNotFound},
+		{Path: filepath.Join(tmpDir, "vendor", "util.libsonnet"), JPath: "vendor", Outcome: CandidateFound},
+	}
+	assertCandidates(t, candidates, want)
+
+	candidates, _ = imp.Explain(from, "util.libsonnet")
+	for _, c := range candidates {
+		if !c.Cached {
+			t.Errorf("second Explain() candidate %v is not cached", c)
+		}
+	}
+
+	candidates, err = imp.Explain(from, "../../escape.jsonnet")
+	if !errors.Is(err, ErrForbiddenRelativePathTraversal) {
+		t.Fatalf("Explain() traversal error = %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Outcome != CandidateOutsideRoot {
+		t.Errorf("Explain() traversal candidates = %v", candidates)
+	}
+}
+
+func TestImport_NotFoundListsCandidates(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	_, _, err = imp.Import(filepath.Join(tmpDir, "main.jsonnet"), "missing.libsonnet")
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("Import() error = %v, want ErrFileNotFound", err)
+	}
+	for _, want := range []string{
+		filepath.Join(tmpDir, "missing.libsonnet") + ": not found",
+		filepath.Join(tmpDir, "lib", "missing.libsonnet") + ": not found",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Import() error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestExplain_NoDuplicateCandidates(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	// With the default "." JPath, the primary candidate and the JPath
+	// candidate are the same file.
+	candidates, err := imp.Explain(filepath.Join(tmpDir, "main.jsonnet"), "missing.libsonnet")
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("Explain() error = %v, want ErrFileNotFound", err)
+	}
+	assertCandidates(t, candidates, []Candidate{
+		{Path: filepath.Join(tmpDir, "missing.libsonnet"), Outcome: CandidateNotFound},
+	})
+}
+
+func assertCandidates(t *testing.T, got, want []Candidate) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d candidates %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Path != w.Path || g.JPath != w.JPath || g.Outcome != w.Outcome {
+			t.Errorf("candidate %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package safesonnet
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// worldWritable is the permission bit granting write access to all users.
+const worldWritable fs.FileMode = 0o002
+
+// FilePolicy restricts which files may be imported based on their metadata.
+// It is checked on the opened file handle, so the inspected file is the one
+// that is actually read.
+type FilePolicy struct {
+	// DenyWorldWritable rejects files that any user on the host can modify.
+	DenyWorldWritable bool
+	// AllowedUIDs, when non-empty, rejects files not owned by one of the listed
+	// user IDs. On platforms without file ownership every file is rejected.
+	AllowedUIDs []int
+}
+
+// WithFilePolicy rejects imports of files violating p. The policy is copied,
+// so later changes to p.AllowedUIDs have no effect.
+func WithFilePolicy(p FilePolicy) Option {
+	p.AllowedUIDs = slices.Clone(p.AllowedUIDs)
+
+	return func(s *SafeImporter) {
+		s.filePolicy = &p
+	}
+}
+
+func (p *FilePolicy) check(fi fs.FileInfo) error {
+	if p.DenyWorldWritable && fi.Mode().Perm()&worldWritable != 0 {
+		return fmt.Errorf("%w: mode %s", ErrWorldWritable, fi.Mode().Perm())
+	}
+	if len(p.AllowedUIDs) == 0 {
+		return nil
+	}
+
+	uid, ok := fileOwner(fi)
+	if !ok {
+		return fmt.Errorf("%w: ownership is not available on this platform", ErrFileOwner)
+	}
+	if !slices.Contains(p.AllowedUIDs, uid) {
+		return fmt.Errorf("%w: owned by uid %d, allowed %v", ErrFileOwner, uid, p.AllowedUIDs)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package safesonnet
+
+import "io/fs"
+
+func fileOwner(fs.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,104 @@
+package safesonnet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestImport_FilePolicy(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and ownership are not enforced on windows")
+	}
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "private.jsonnet"), `{x: 1}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "shared.jsonnet"), `{y: 2}`)
+	if err := os.Chmod(filepath.Join(tmpDir, "shared.jsonnet"), 0o666); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		policy       FilePolicy
+		importedPath string
+		wantErr      error
+	}{
+		{
+			name:         "world-writable denied",
+			policy:       FilePolicy{DenyWorldWritable: true},
+			importedPath: "shared.jsonnet",
+			wantErr:      ErrWorldWritable,
+		},
+		{
+			name:         "world-writable allowed without policy flag",
+			policy:       FilePolicy{},
+			importedPath: "shared.jsonnet",
+		},
+		{
+			name:         "private file passes",
+			policy:       FilePolicy{DenyWorldWritable: true, AllowedUIDs: []int{os.Getuid()}},
+			importedPath: "private.jsonnet",
+		},
+		{
+			name:         "unexpected owner denied",
+			policy:       FilePolicy{AllowedUIDs: []int{os.Getuid() + 1}},
+			importedPath: "private.jsonnet",
+			wantErr:      ErrFileOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			imp, err := NewSafeImporter(tmpDir, nil, WithFilePolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("NewSafeImporter() error = %v", err)
+			}
+			defer imp.Close()
+
+			_, _, err = imp.Import("", filepath.Join(tmpDir, tt.importedPath))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Import() error = %v, want nil", err)
+				}
+
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Import() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithFilePolicy_CopiesAllowedUIDs(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file ownership is not enforced on windows")
+	}
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "private.jsonnet"), `{x: 1}`)
+
+	uids := []int{os.Getuid() + 1}
+	imp, err := NewSafeImporter(tmpDir, nil, WithFilePolicy(FilePolicy{AllowedUIDs: uids}))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	// Changing the caller's slice must not widen the policy.
+	uids[0] = os.Getuid()
+
+	_, _, err = imp.Import("", filepath.Join(tmpDir, "private.jsonnet"))
+	if !errors.Is(err, ErrFileOwner) {
+		t.Errorf("Import() error = %v, want ErrFileOwner", err)
+	}
+}
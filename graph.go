@@ -0,0 +1,154 @@
+package safesonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// ImportEdge is a resolved import. Paths inside the root are root-relative;
+// other paths are kept as reported to the importer.
+type ImportEdge struct {
+	// From is the importing file, or empty for the evaluation entrypoint.
+	From string `json:"from"`
+	// To is the file the import resolved to.
+	To string `json:"to"`
+}
+
+// ImportGraph records the imports resolved by a SafeImporter. It is safe for
+// concurrent use.
+type ImportGraph struct {
+	mu    sync.Mutex
+	root  string
+	edges []ImportEdge
+	seen  map[ImportEdge]struct{}
+}
+
+// NewImportGraph returns an empty graph.
+func NewImportGraph() *ImportGraph {
+	return &ImportGraph{seen: make(map[ImportEdge]struct{})}
+}
+
+// WithImportGraph records every successful Import into g.
+func WithImportGraph(g *ImportGraph) Option {
+	return func(s *SafeImporter) {
+		if g == nil {
+			return
+		}
+
+		g.mu.Lock()
+		if g.root == "" {
+			g.root = s.rootAbsPath
+		}
+		g.mu.Unlock()
+		s.graph = g
+	}
+}
+
+func (g *ImportGraph) record(importedFrom, foundAt string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e := ImportEdge{From: g.rel(importedFrom), To: g.rel(foundAt)}
+	if _, ok := g.seen[e]; ok {
+		return
+	}
+
+	g.seen[e] = struct{}{}
+	g.edges = append(g.edges, e)
+}
+
+func (g *ImportGraph) rel(path string) string {
+	if path == "" {
+		return ""
+	}
+	if rel, inside, err := relToRoot(g.root, path); err == nil && inside {
+		return rel
+	}
+
+	return path
+}
+
+// Root returns the absolute root directory that edge paths are relative to.
+func (g *ImportGraph) Root() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.root
+}
+
+// Edges returns the recorded edges in the order they were first seen.
+func (g *ImportGraph) Edges() []ImportEdge {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.edges)
+}
+
+// Files returns every file that was imported, sorted.
+func (g *ImportGraph) Files() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	files := make([]string, 0, len(g.edges))
+	for _, e := range g.edges {
+		files = append(files, e.To)
+	}
+	slices.Sort(files)
+
+	return slices.Compact(files)
+}
+
+// Reset discards all recorded edges.
+func (g *ImportGraph) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.edges = nil
+	clear(g.seen)
+}
+
+// WriteJSON writes the graph as a JSON object with root, files and edges.
+func (g *ImportGraph) WriteJSON(w io.Writer) error {
+	doc := struct {
+		Root  string       `json:"root"`
+		Files []string     `json:"files"`
+		Edges []ImportEdge `json:"edges"`
+	}{
+		Root:  g.Root(),
+		Files: g.Files(),
+		Edges: g.Edges(),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Entrypoints are drawn as
+// boxes.
+func (g *ImportGraph) WriteDOT(w io.Writer) error {
+	edges := g.Edges()
+
+	if _, err := io.WriteString(w, "digraph imports {\n"); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		var err error
+		if e.From == "" {
+			_, err = fmt.Fprintf(w, "  %s [shape=box];\n", strconv.Quote(e.To))
+		} else {
+			_, err = fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+
+	return err
+}
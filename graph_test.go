@@ -0,0 +1,83 @@
+package safesonnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+)
+
+func TestImportGraph(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`(import 'a.libsonnet') + (import 'lib/b.libsonnet')`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "a.libsonnet"), `import 'b.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "b.libsonnet"), `{b: true}`)
+
+	graph := NewImportGraph()
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"}, WithImportGraph(graph))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(imp)
+	if _, err := vm.EvaluateFile(filepath.Join(tmpDir, "main.jsonnet")); err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+
+	a := filepath.Join("lib", "a.libsonnet")
+	b := filepath.Join("lib", "b.libsonnet")
+	wantEdges := []ImportEdge{
+		{From: "", To: "main.jsonnet"},
+		{From: "main.jsonnet", To: a},
+		{From: a, To: b},
+		{From: "main.jsonnet", To: b},
+	}
+	if got := graph.Edges(); !slices.Equal(got, wantEdges) {
+		t.Errorf("Edges() = %v, want %v", got, wantEdges)
+	}
+	if got, want := graph.Files(), []string{a, b, "main.jsonnet"}; !slices.Equal(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	for _, want := range []string{
+		`"main.jsonnet" [shape=box];`,
+		`"main.jsonnet" -> "` + filepath.ToSlash(a) + `";`,
+	} {
+		if !strings.Contains(filepath.ToSlash(dot.String()), want) {
+			t.Errorf("WriteDOT() output %q does not contain %q", dot.String(), want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := graph.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var doc struct {
+		Root  string       `json:"root"`
+		Edges []ImportEdge `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON: %v", err)
+	}
+	if doc.Root != imp.rootAbsPath || len(doc.Edges) != len(wantEdges) {
+		t.Errorf("WriteJSON() = %s", buf.String())
+	}
+
+	graph.Reset()
+	if len(graph.Edges()) != 0 {
+		t.Error("Reset() did not clear edges")
+	}
+}
@@ -0,0 +1,139 @@
+package safesonnet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-jsonnet"
+)
+
+// HookEvent describes an import as it passes through the hook chain. Hooks
+// may modify the event to influence the rest of the import.
+type HookEvent struct {
+	// ImportedFrom is the file containing the import, or empty for the entrypoint.
+	ImportedFrom string
+	// ImportedPath is the path as written in the import expression. BeforeResolve
+	// hooks may rewrite it.
+	ImportedPath string
+	// RelPath is the resolved path relative to the root directory. It is empty
+	// before resolution.
+	RelPath string
+	// FoundAt is the absolute path reported to go-jsonnet. Setting it in a
+	// BeforeResolve hook injects Contents as the imported file and skips
+	// resolution and all remaining hooks.
+	FoundAt string
+	// Contents holds the file contents. AfterLoad hooks may replace it. The
+	// contents produced by the first import of a FoundAt are returned for every
+	// later import of it, as go-jsonnet requires.
+	Contents jsonnet.Contents
+}
+
+// Hook intercepts imports. Any phase may veto the import by returning an
+// error, which is wrapped in ErrImportRejected. Nil phases are skipped.
+type Hook struct {
+	// BeforeResolve runs before the import path is resolved.
+	BeforeResolve func(ev *HookEvent) error
+	// AfterResolve runs once the file has been located inside the root.
+	AfterResolve func(ev *HookEvent) error
+	// AfterLoad runs last and may post-process the contents.
+	AfterLoad func(ev *HookEvent) error
+}
+
+// WithHooks appends hooks to the import chain. Hooks run in the order given,
+// phase by phase.
+func WithHooks(hooks ...Hook) Option {
+	return func(s *SafeImporter) {
+		s.hooks = append(s.hooks, hooks...)
+	}
+}
+
+// importWithHooks resolves r through the hook chain. BeforeResolve hooks may
+// rewrite r.importedPath.
+func (s *SafeImporter) importWithHooks(ctx context.Context, r *resolution) (jsonnet.Contents, string, error) {
+	importedFrom := r.importedFrom
+	if err := s.validatePaths(importedFrom, r.importedPath); err != nil {
+		return jsonnet.Contents{}, "", err
+	}
+
+	ev := &HookEvent{ImportedFrom: importedFrom, ImportedPath: r.importedPath}
+	for _, h := range s.hooks {
+		if err := s.runHook(h.BeforeResolve, ev); err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+		if ev.FoundAt != "" {
+			s.audit(AuditEvent{
+				Stage:        AuditStageHook,
+				ImportedFrom: importedFrom,
+				ImportedPath: ev.ImportedPath,
+				ResolvedPath: ev.FoundAt,
+				Decision:     AuditAllow,
+			})
+
+			return s.stableContents(ev.FoundAt, ev.Contents), ev.FoundAt, nil
+		}
+	}
+
+	r.importedPath = ev.ImportedPath
+	contents, foundAt, err := s.resolve(ctx, r)
+	if err != nil {
+		return jsonnet.Contents{}, "", err
+	}
+	ev.Contents, ev.FoundAt = contents, foundAt
+	ev.RelPath, _, _ = relToRoot(s.rootAbsPath, foundAt)
+
+	for _, h := range s.hooks {
+		if err := s.runHook(h.AfterResolve, ev); err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+	}
+	for _, h := range s.hooks {
+		if err := s.runHook(h.AfterLoad, ev); err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+	}
+
+	return s.stableContents(ev.FoundAt, ev.Contents), ev.FoundAt, nil
+}
+
+// stableContents returns the contents first returned for foundAt. go-jsonnet
+// calls Import on every evaluation of an import expression and fails if a path
+// comes back with a different Contents instance, which hooks creating new
+// contents would otherwise cause.
+func (s *SafeImporter) stableContents(foundAt string, contents jsonnet.Contents) jsonnet.Contents {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if prev, ok := s.hookContents[foundAt]; ok {
+		return prev
+	}
+	s.hookContents[foundAt] = contents
+
+	return contents
+}
+
+func (s *SafeImporter) runHook(fn func(*HookEvent) error, ev *HookEvent) error {
+	if fn == nil {
+		return nil
+	}
+	if err := fn(ev); err != nil {
+		err = &ImportError{
+			Op:           OpHook,
+			ImportedFrom: ev.ImportedFrom,
+			ImportedPath: ev.ImportedPath,
+			ResolvedPath: ev.FoundAt,
+			Err:          fmt.Errorf("%w: %w", ErrImportRejected, err),
+		}
+		s.audit(AuditEvent{
+			Stage:        AuditStageHook,
+			ImportedFrom: ev.ImportedFrom,
+			ImportedPath: ev.ImportedPath,
+			ResolvedPath: ev.FoundAt,
+			Decision:     AuditDeny,
+			Err:          err,
+		})
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+)
+
+var errVetoed = errors.New("vetoed")
+
+func TestImport_Hooks(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "v2.libsonnet"), `{v: 2}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "secret.jsonnet"), `{secret: true}`)
+
+	rewrite := Hook{
+		BeforeResolve: func(ev *HookEvent) error {
+			ev.ImportedPath = strings.Replace(ev.ImportedPath, "v1", "v2", 1)
+
+			return nil
+		},
+	}
+	inject := Hook{
+		BeforeResolve: func(ev *HookEvent) error {
+			if ev.ImportedPath == "generated.libsonnet" {
+				ev.FoundAt = filepath.Join(tmpDir, "generated.libsonnet")
+				ev.Contents = jsonnet.MakeContents(`{generated: true}`)
+			}
+
+			return nil
+		},
+	}
+	veto := Hook{
+		AfterResolve: func(ev *HookEvent) error {
+			if ev.RelPath == "secret.jsonnet" {
+				return errVetoed
+			}
+
+			return nil
+		},
+	}
+	annotate := Hook{
+		AfterLoad: func(ev *HookEvent) error {
+			ev.Contents = jsonnet.MakeContents("// " + ev.RelPath + "\n" + ev.Contents.String())
+
+			return nil
+		},
+	}
+
+	imp, err := NewSafeImporter(tmpDir, nil, WithHooks(rewrite, inject, veto, annotate))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	from := filepath.Join(tmpDir, "main.jsonnet")
+
+	contents, foundAt, err := imp.Import(from, "lib/v1.libsonnet")
+	if err != nil {
+		t.Fatalf("Import() rewritten path error = %v", err)
+	}
+	if want := "// " + filepath.Join("lib", "v2.libsonnet") + "\n{v: 2}"; contents.String() != want {
+		t.Errorf("Import() contents = %q, want %q", contents.String(), want)
+	}
+	if foundAt != filepath.Join(tmpDir, "lib", "v2.libsonnet") {
+		t.Errorf("Import() foundAt = %q", foundAt)
+	}
+
+	contents, _, err = imp.Import(from, "generated.libsonnet")
+	if err != nil {
+		t.Fatalf("Import() injected file error = %v", err)
+	}
+	if contents.String() != `{generated: true}` {
+		t.Errorf("Import() injected contents = %q", contents.String())
+	}
+
+	_, _, err = imp.Import(from, "secret.jsonnet")
+	if !errors.Is(err, ErrImportRejected) || !errors.Is(err, errVetoed) {
+		t.Errorf("Import() vetoed error = %v, want ErrImportRejected wrapping errVetoed", err)
+	}
+
+	if _, _, err = imp.Import(from, "bad\x00path"); !errors.Is(err, ErrInvalidNullByte) {
+		t.Errorf("Import() null byte error = %v, want ErrInvalidNullByte", err)
+	}
+}
+
+func TestImport_HooksSharedImport(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`[import 'lib.libsonnet', import 'a.jsonnet', import 'gen.libsonnet']`)
+	mustWriteFile(t, filepath.Join(tmpDir, "a.jsonnet"), `[import 'lib.libsonnet', import 'gen.libsonnet']`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib.libsonnet"), `'lib'`)
+
+	inject := Hook{
+		BeforeResolve: func(ev *HookEvent) error {
+			if ev.ImportedPath == "gen.libsonnet" {
+				ev.FoundAt = filepath.Join(tmpDir, "gen.libsonnet")
+				ev.Contents = jsonnet.MakeContents(`'gen'`)
+			}
+
+			return nil
+		},
+	}
+	upper := Hook{
+		AfterLoad: func(ev *HookEvent) error {
+			if ev.RelPath == "lib.libsonnet" {
+				ev.Contents = jsonnet.MakeContents("std.asciiUpper(" + ev.Contents.String() + ")")
+			}
+
+			return nil
+		},
+	}
+
+	imp, err := NewSafeImporter(tmpDir, nil, WithHooks(inject, upper))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	out, err := NewVM(imp, VMOptions{}).EvaluateFile(filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+	if want := `["LIB",["LIB","gen"],"gen"]`; strings.Join(strings.Fields(out), "") != want {
+		t.Errorf("EvaluateFile() = %q, want %q", out, want)
+	}
+}
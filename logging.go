@@ -0,0 +1,61 @@
+package safesonnet
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithSlogLogger emits structured records for import resolution. Resolution
+// steps are logged at debug level, denied and failed imports at warn level.
+func WithSlogLogger(l *slog.Logger) Option {
+	return func(s *SafeImporter) {
+		if l != nil {
+			s.slog = l
+		}
+	}
+}
+
+func (s *SafeImporter) logLookup(ctx context.Context, candidate, jpath string, res fileLookup, err error) {
+	attrs := []slog.Attr{
+		slog.String("candidate", candidate),
+		slog.Bool("cache", res.cached),
+		slog.Bool("found", res.found),
+	}
+	if jpath != "" {
+		attrs = append(attrs, slog.String("jpath", jpath))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	s.slog.LogAttrs(ctx, slog.LevelDebug, "import candidate", attrs...)
+}
+
+func (s *SafeImporter) logImport(
+	ctx context.Context,
+	importedFrom, importedPath, foundAt string,
+	cached bool,
+	start time.Time,
+	err error,
+) {
+	attrs := []slog.Attr{
+		slog.String("from", importedFrom),
+		slog.String("path", importedPath),
+		slog.Duration("duration", time.Since(start)),
+	}
+
+	switch importOutcome(err) {
+	case OutcomeOK:
+		attrs = append(attrs, slog.String("foundAt", foundAt), slog.Bool("cache", cached))
+		s.slog.LogAttrs(ctx, slog.LevelDebug, "import resolved", attrs...)
+	case OutcomeNotFound:
+		s.slog.LogAttrs(ctx, slog.LevelDebug, "import not found", attrs...)
+	case OutcomeDenied:
+		attrs = append(attrs, slog.Any("error", err))
+		s.slog.LogAttrs(ctx, slog.LevelWarn, "import denied", attrs...)
+	case OutcomeError:
+		attrs = append(attrs, slog.Any("error", err))
+		s.slog.LogAttrs(ctx, slog.LevelWarn, "import failed", attrs...)
+	}
+}
@@ -0,0 +1,64 @@
+package safesonnet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport_SlogLogger(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	imp, err := NewSafeImporter(tmpDir, nil, WithSlogLogger(logger))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	from := filepath.Join(tmpDir, "main.jsonnet")
+	if _, _, err := imp.Import("", from); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	// The second import is served from the cache and logged last.
+	if _, _, err := imp.Import("", from); err != nil {
+		t.Fatalf("second Import() error = %v", err)
+	}
+	if _, _, err := imp.Import(from, "../../escape.jsonnet"); err == nil {
+		t.Fatal("Import() traversal should fail")
+	}
+
+	records := map[string]map[string]any{}
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var rec map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("record %q is not JSON: %v", sc.Text(), err)
+		}
+		msg, _ := rec["msg"].(string)
+		records[msg] = rec
+	}
+
+	candidate := records["import candidate"]
+	if candidate == nil || candidate["level"] != "DEBUG" || candidate["cache"] != true {
+		t.Errorf("import candidate record = %v", candidate)
+	}
+	resolved := records["import resolved"]
+	if resolved == nil || resolved["level"] != "DEBUG" || resolved["foundAt"] != from || resolved["cache"] != true {
+		t.Errorf("import resolved record = %v", resolved)
+	}
+	if _, ok := resolved["duration"]; !ok {
+		t.Errorf("import resolved record has no duration: %v", resolved)
+	}
+	denied := records["import denied"]
+	if denied == nil || denied["level"] != "WARN" || denied["path"] != "../../escape.jsonnet" {
+		t.Errorf("import denied record = %v", denied)
+	}
+}
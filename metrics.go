@@ -0,0 +1,72 @@
+package safesonnet
+
+import (
+	"errors"
+	"time"
+)
+
+// ImportOutcome classifies the result of an Import call for metrics.
+type ImportOutcome string
+
+const (
+	// OutcomeOK means the import succeeded.
+	OutcomeOK ImportOutcome = "ok"
+	// OutcomeNotFound means the file was not found in any search path.
+	OutcomeNotFound ImportOutcome = "not_found"
+	// OutcomeDenied means the import violated the sandbox or a policy.
+	OutcomeDenied ImportOutcome = "denied"
+	// OutcomeError means the import failed for any other reason, such as a read error.
+	OutcomeError ImportOutcome = "error"
+)
+
+// Metrics receives measurements from SafeImporter. Implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	// ImportCompleted is called once per Import with its outcome and duration.
+	ImportCompleted(outcome ImportOutcome, d time.Duration)
+	// ImportDenied is called for denied imports with the matching package sentinel.
+	ImportDenied(sentinel error)
+	// CacheLookup is called for every candidate file lookup.
+	CacheLookup(hit bool)
+	// FileLoaded is called after a file is read from disk with its size and
+	// the time taken to open, check and read it.
+	FileLoaded(bytes int, d time.Duration)
+}
+
+// WithMetrics reports import measurements to m.
+func WithMetrics(m Metrics) Option {
+	return func(s *SafeImporter) {
+		if m != nil {
+			s.metrics = m
+		}
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ImportCompleted(ImportOutcome, time.Duration) {}
+func (nopMetrics) ImportDenied(error)                           {}
+func (nopMetrics) CacheLookup(bool)                             {}
+func (nopMetrics) FileLoaded(int, time.Duration)                {}
+
+func (s *SafeImporter) measureImport(start time.Time, err error) {
+	outcome := importOutcome(err)
+	if outcome == OutcomeDenied {
+		s.metrics.ImportDenied(sentinelOf(err))
+	}
+
+	s.metrics.ImportCompleted(outcome, time.Since(start))
+}
+
+func importOutcome(err error) ImportOutcome {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrFileNotFound):
+		return OutcomeNotFound
+	case IsDenied(err):
+		return OutcomeDenied
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,150 @@
+package safesonnet
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// NativeFunctions returns std.native functions that inspect files through the
+// importer's root:
+//
+//   - listDir(path) returns the sorted entry names of a directory.
+//   - exists(path) reports whether a file or directory exists.
+//   - glob(pattern) returns the sorted root-relative paths matching a
+//     path.Match pattern.
+//   - sha256File(path) returns the hex SHA-256 digest of a file.
+//
+// Paths are relative to the root directory, or absolute paths inside it.
+// Paths outside the root are rejected with the same sentinels as Import.
+// sha256File reads like Import: only regular files are read, the file policy
+// applies and reads are bounded by WithReadTimeout.
+func (s *SafeImporter) NativeFunctions() []*jsonnet.NativeFunction {
+	return []*jsonnet.NativeFunction{
+		{Name: "listDir", Params: ast.Identifiers{"path"}, Func: s.nativeListDir},
+		{Name: "exists", Params: ast.Identifiers{"path"}, Func: s.nativeExists},
+		{Name: "glob", Params: ast.Identifiers{"pattern"}, Func: s.nativeGlob},
+		{Name: "sha256File", Params: ast.Identifiers{"path"}, Func: s.nativeSHA256File},
+	}
+}
+
+// RegisterNativeFunctions registers NativeFunctions with vm.
+func (s *SafeImporter) RegisterNativeFunctions(vm *jsonnet.VM) {
+	for _, f := range s.NativeFunctions() {
+		vm.NativeFunction(f)
+	}
+}
+
+func (s *SafeImporter) nativeListDir(args []any) (any, error) {
+	rel, err := s.nativePath(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(s.root.FS(), filepath.ToSlash(rel))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+
+	names := make([]any, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names, nil
+}
+
+func (s *SafeImporter) nativeExists(args []any) (any, error) {
+	rel, err := s.nativePath(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.root.Stat(rel); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+
+	return true, nil
+}
+
+func (s *SafeImporter) nativeGlob(args []any) (any, error) {
+	rel, err := s.nativePath(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := fs.Glob(s.root.FS(), filepath.ToSlash(rel))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+
+	paths := make([]any, 0, len(matches))
+	for _, m := range matches {
+		paths = append(paths, m)
+	}
+
+	return paths, nil
+}
+
+func (s *SafeImporter) nativeSHA256File(args []any) (any, error) {
+	rel, err := s.nativePath(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.readFileTimeout(context.Background(), rel)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+		}
+
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// nativePath validates a native function path argument and returns it
+// relative to the root.
+func (s *SafeImporter) nativePath(arg any) (string, error) {
+	p, ok := arg.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: want a string path, got %T", ErrInvalidArgument, arg)
+	}
+	if strings.Contains(p, "\x00") {
+		return "", ErrInvalidNullByte
+	}
+
+	isAbs := filepath.IsAbs(p)
+	abs := p
+	if !isAbs {
+		abs = filepath.Join(s.rootAbsPath, p)
+	}
+
+	rel, inside, err := relToRoot(s.rootAbsPath, abs)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+	if !inside {
+		if isAbs {
+			return "", fmt.Errorf("%w: outside root directory %q", ErrForbiddenAbsolutePath, s.rootAbsPath)
+		}
+
+		return "", fmt.Errorf("%w: outside root directory %q", ErrForbiddenRelativePathTraversal, s.rootAbsPath)
+	}
+
+	return rel, nil
+}
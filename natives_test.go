@@ -0,0 +1,128 @@
+package safesonnet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNativeFunctions(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "a.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "b.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "notes.txt"), `hello`)
+	if err := os.Mkdir(filepath.Join(tmpDir, "lib", "sub"), 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	sum := sha256.Sum256([]byte("hello"))
+
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "listDir",
+			snippet: `std.native('listDir')('lib')`,
+			want:    `["a.libsonnet","b.libsonnet","notes.txt","sub"]`,
+		},
+		{
+			name:    "listDir absolute",
+			snippet: `std.native('listDir')('` + filepath.Join(tmpDir, "lib", "sub") + `')`,
+			want:    `[]`,
+		},
+		{
+			name:    "exists",
+			snippet: `[std.native('exists')(p) for p in ['lib/a.libsonnet', 'lib/sub', 'missing']]`,
+			want:    `[true,true,false]`,
+		},
+		{
+			name:    "glob",
+			snippet: `std.native('glob')('lib/*.libsonnet')`,
+			want:    `["lib/a.libsonnet","lib/b.libsonnet"]`,
+		},
+		{
+			name:    "sha256File",
+			snippet: `std.native('sha256File')('lib/notes.txt')`,
+			want:    `"` + hex.EncodeToString(sum[:]) + `"`,
+		},
+		{
+			name:    "relative traversal",
+			snippet: `std.native('exists')('../outside')`,
+			wantErr: ErrForbiddenRelativePathTraversal,
+		},
+		{
+			name:    "absolute outside root",
+			snippet: `std.native('listDir')('/')`,
+			wantErr: ErrForbiddenAbsolutePath,
+		},
+		{
+			name:    "traversal in glob",
+			snippet: `std.native('glob')('../*')`,
+			wantErr: ErrForbiddenRelativePathTraversal,
+		},
+		{
+			name:    "non-string argument",
+			snippet: `std.native('sha256File')(42)`,
+			wantErr: ErrInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			vm := NewVM(imp, VMOptions{NativeFunctions: imp.NativeFunctions()})
+			out, err := vm.EvaluateAnonymousSnippet("test.jsonnet", `std.manifestJsonMinified(`+tt.snippet+`)`)
+			if tt.wantErr != nil {
+				// go-jsonnet flattens native function errors into the runtime error message.
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr.Error()) {
+					t.Fatalf("EvaluateAnonymousSnippet() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateAnonymousSnippet() error = %v", err)
+			}
+			if got := strings.TrimSpace(out); got != `"`+strings.ReplaceAll(tt.want, `"`, `\"`)+`"` {
+				t.Errorf("EvaluateAnonymousSnippet() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeFunctions_FilePolicy(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "open.txt"), `data`)
+	if err := os.Chmod(filepath.Join(tmpDir, "open.txt"), 0o666); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+
+	imp, err := NewSafeImporter(tmpDir, nil, WithFilePolicy(FilePolicy{DenyWorldWritable: true}))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	_, err = imp.nativeSHA256File([]any{"open.txt"})
+	if !errors.Is(err, ErrWorldWritable) {
+		t.Errorf("sha256File() error = %v, want ErrWorldWritable", err)
+	}
+}
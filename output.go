@@ -0,0 +1,241 @@
+package safesonnet
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// OutputFormat selects how an OutputWriter encodes the files it writes.
+type OutputFormat string
+
+const (
+	// FormatJSON writes outputs unchanged, as produced by the VM.
+	FormatJSON OutputFormat = "json"
+	// FormatYAML converts JSON outputs to YAML.
+	FormatYAML OutputFormat = "yaml"
+)
+
+// DefaultOutputFileMode is the permission used for files written by an
+// OutputWriter unless WithOutputFileMode is set.
+const DefaultOutputFileMode fs.FileMode = 0o644
+
+const outputDirMode fs.FileMode = 0o755
+
+// OutputWriter writes evaluation outputs to files confined to an output
+// directory. Output names are validated like import paths, and writes go
+// through os.Root, so neither a key such as "../../etc/cron.d/x" nor a
+// symlink inside the directory can place a file outside it. Each file is
+// written to a temporary file and renamed into place, so readers never see a
+// partial write.
+type OutputWriter struct {
+	root        *os.Root
+	rootAbsPath string
+	format      OutputFormat
+	perm        fs.FileMode
+}
+
+// OutputOption configures OutputWriter.
+type OutputOption func(*OutputWriter)
+
+// WithOutputFormat sets the format of written files. The default is FormatJSON.
+func WithOutputFormat(f OutputFormat) OutputOption {
+	return func(w *OutputWriter) {
+		w.format = f
+	}
+}
+
+// WithOutputFileMode sets the permission of written files, before the umask.
+func WithOutputFileMode(perm fs.FileMode) OutputOption {
+	return func(w *OutputWriter) {
+		w.perm = perm
+	}
+}
+
+// NewOutputWriter creates a writer confined to dir, which must exist.
+func NewOutputWriter(dir string, opts ...OutputOption) (*OutputWriter, error) {
+	if dir == "" {
+		return nil, ErrEmptyRootDir
+	}
+
+	rootAbs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAbsPath, err)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenRootDir, err)
+	}
+
+	w := &OutputWriter{
+		root:        root,
+		rootAbsPath: rootAbs,
+		format:      FormatJSON,
+		perm:        DefaultOutputFileMode,
+	}
+	for _, o := range opts {
+		o(w)
+	}
+
+	return w, nil
+}
+
+// Root returns the absolute path of the output directory.
+func (w *OutputWriter) Root() string {
+	return w.rootAbsPath
+}
+
+// WriteMulti writes the outputs of jsonnet.VM.EvaluateFileMulti, one file per
+// key, and returns the paths written relative to the output directory in key
+// order. All keys are validated before anything is written, including that no
+// two keys write the same file and that no key writes inside another's file.
+func (w *OutputWriter) WriteMulti(outputs map[string]string) ([]string, error) {
+	keys := slices.Sorted(maps.Keys(outputs))
+
+	paths := make([]string, 0, len(keys))
+	for _, k := range keys {
+		rel, err := outputPath(k)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, rel)
+	}
+	if err := checkOutputPaths(keys, paths); err != nil {
+		return nil, err
+	}
+
+	for i, k := range keys {
+		if err := w.write(paths[i], []byte(outputs[k])); err != nil {
+			return paths[:i], err
+		}
+	}
+
+	return paths, nil
+}
+
+// WriteFile encodes data in the writer's format and writes it to name, which
+// is relative to the output directory.
+func (w *OutputWriter) WriteFile(name string, data []byte) error {
+	rel, err := outputPath(name)
+	if err != nil {
+		return err
+	}
+
+	return w.write(rel, data)
+}
+
+// Close releases the output directory.
+func (w *OutputWriter) Close() error {
+	return w.root.Close()
+}
+
+func (w *OutputWriter) write(rel string, data []byte) error {
+	encoded, err := encodeOutput(w.format, data)
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrWriteFile, rel, err)
+	}
+
+	return w.put(rel, encoded)
+}
+
+// put writes already encoded data to rel.
+func (w *OutputWriter) put(rel string, data []byte) error {
+	if err := w.writeAtomic(rel, data); err != nil {
+		return fmt.Errorf("%w %q: %w", ErrWriteFile, rel, err)
+	}
+
+	return nil
+}
+
+// writeAtomic writes data to a temporary file next to rel and renames it
+// into place.
+func (w *OutputWriter) writeAtomic(rel string, data []byte) error {
+	dir := filepath.Dir(rel)
+	if err := w.root.MkdirAll(dir, outputDirMode); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, "."+filepath.Base(rel)+".tmp-"+rand.Text())
+	f, err := w.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, w.perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err = errors.Join(err, f.Close()); err == nil {
+		err = w.root.Rename(tmp, rel)
+	}
+	if err != nil {
+		_ = w.root.Remove(tmp)
+
+		return err
+	}
+
+	return nil
+}
+
+// outputPath validates an output name and returns it as a clean path relative
+// to the output directory.
+func outputPath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: empty output name", ErrWriteFile)
+	}
+	if strings.Contains(name, "\x00") {
+		return "", ErrInvalidNullByte
+	}
+
+	p := filepath.FromSlash(name)
+	if filepath.IsAbs(p) {
+		return "", fmt.Errorf("%w: output %q", ErrForbiddenAbsolutePath, name)
+	}
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w: output %q", ErrForbiddenRelativePathTraversal, name)
+	}
+
+	return filepath.Clean(p), nil
+}
+
+// checkOutputPaths rejects outputs that would overwrite each other, or where
+// one output would need another to be a directory.
+func checkOutputPaths(keys, paths []string) error {
+	files := make(map[string]string, len(paths))
+	for i, p := range paths {
+		if prev, ok := files[p]; ok {
+			return fmt.Errorf("%w: outputs %q and %q both write %q", ErrWriteFile, prev, keys[i], p)
+		}
+		files[p] = keys[i]
+	}
+
+	for i, p := range paths {
+		for dir := filepath.Dir(p); dir != "."; dir = filepath.Dir(dir) {
+			if prev, ok := files[dir]; ok {
+				return fmt.Errorf("%w: output %q is inside output %q", ErrWriteFile, keys[i], prev)
+			}
+		}
+	}
+
+	return nil
+}
+
+func encodeOutput(format OutputFormat, data []byte) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatYAML:
+		return yaml.JSONToYAML(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
@@ -0,0 +1,189 @@
+package safesonnet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOutputWriter_WriteMulti(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`{ 'a.json': { x: 1 }, 'nested/b.json': { y: [1, 2] } }`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	outputs, err := NewVM(imp, VMOptions{}).EvaluateFileMulti(filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFileMulti() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		format OutputFormat
+		want   map[string]string
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			want: map[string]string{
+				"a.json":        "{\n   \"x\": 1\n}\n",
+				"nested/b.json": "{\n   \"y\": [\n      1,\n      2\n   ]\n}\n",
+			},
+		},
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			want: map[string]string{
+				"a.json":        "x: 1\n",
+				"nested/b.json": "\"y\":\n- 1\n- 2\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			outDir := t.TempDir()
+			w, err := NewOutputWriter(outDir, WithOutputFormat(tt.format))
+			if err != nil {
+				t.Fatalf("NewOutputWriter() error = %v", err)
+			}
+			t.Cleanup(func() { w.Close() })
+
+			paths, err := w.WriteMulti(outputs)
+			if err != nil {
+				t.Fatalf("WriteMulti() error = %v", err)
+			}
+			if want := []string{"a.json", filepath.Join("nested", "b.json")}; !slices.Equal(paths, want) {
+				t.Errorf("WriteMulti() = %v, want %v", paths, want)
+			}
+
+			for name, want := range tt.want {
+				got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("ReadFile(%s) error = %v", name, err)
+				}
+				if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+
+			entries, err := os.ReadDir(outDir)
+			if err != nil {
+				t.Fatalf("ReadDir() error = %v", err)
+			}
+			if len(entries) != 2 {
+				t.Errorf("output directory has %d entries, want 2 (no temporary files)", len(entries))
+			}
+		})
+	}
+}
+
+func TestOutputWriter_RejectsUnsafeNames(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+	outDir := filepath.Join(parent, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+
+	w, err := NewOutputWriter(outDir)
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "traversal", key: "../escape.json", wantErr: ErrForbiddenRelativePathTraversal},
+		{name: "nested traversal", key: "a/../../escape.json", wantErr: ErrForbiddenRelativePathTraversal},
+		{name: "absolute", key: filepath.Join(parent, "escape.json"), wantErr: ErrForbiddenAbsolutePath},
+		{name: "null byte", key: "a\x00.json", wantErr: ErrInvalidNullByte},
+		{name: "empty", key: "", wantErr: ErrWriteFile},
+		{name: "same file", key: "./ok.json", wantErr: ErrWriteFile},
+		{name: "inside another output", key: "ok.json/nested.json", wantErr: ErrWriteFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := w.WriteMulti(map[string]string{"ok.json": "{}\n", tt.key: "{}\n"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WriteMulti() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		entries, err := os.ReadDir(outDir)
+		if err != nil {
+			t.Fatalf("ReadDir() error = %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("output directory has %d entries, want nothing written", len(entries))
+		}
+	})
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("parent directory has %d entries, want only the output directory", len(entries))
+	}
+}
+
+func TestOutputWriter_SymlinkEscape(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+	outDir := filepath.Join(parent, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := os.Symlink(parent, filepath.Join(outDir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	w, err := NewOutputWriter(outDir)
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	if err := w.WriteFile("link/escape.json", []byte("{}\n")); !errors.Is(err, ErrWriteFile) {
+		t.Errorf("WriteFile() error = %v, want ErrWriteFile", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape.json")); !os.IsNotExist(err) {
+		t.Errorf("file was written outside the output directory: %v", err)
+	}
+}
+
+func TestOutputWriter_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	w, err := NewOutputWriter(t.TempDir(), WithOutputFormat("toml"))
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	if err := w.WriteFile("a.toml", []byte("{}")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("WriteFile() error = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,35 @@
+package safesonnet
+
+import "context"
+
+// ResolvedImport is the location an import resolves to.
+type ResolvedImport struct {
+	// FoundAt is the absolute path, as Import would report it.
+	FoundAt string
+	// RelPath is FoundAt relative to the root directory.
+	RelPath string
+	// JPath is the library path the file was found in, or empty when it was
+	// found relative to the importing file.
+	JPath string
+}
+
+// Resolve reports where an import resolves without reading the file. It
+// applies the same rules as Import, including the file policy, but only stats
+// candidates through the root. Hooks are not run.
+func (s *SafeImporter) Resolve(importedFrom, importedPath string) (ResolvedImport, error) {
+	r := newResolution(importedFrom, importedPath)
+	r.statOnly = true
+
+	_, foundAt, err := s.resolve(context.Background(), r)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	ri := ResolvedImport{FoundAt: foundAt}
+	ri.RelPath, _, _ = relToRoot(s.rootAbsPath, foundAt)
+	if last := r.candidates[len(r.candidates)-1]; last.Outcome == CandidateFound {
+		ri.JPath = last.JPath
+	}
+
+	return ri, nil
+}
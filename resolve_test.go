@@ -0,0 +1,95 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "app", "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "app", "local.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "util.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "pkg", "x"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	from := filepath.Join(tmpDir, "app", "main.jsonnet")
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         ResolvedImport
+		wantErr      error
+	}{
+		{
+			name:         "relative to importer",
+			importedPath: "local.libsonnet",
+			want: ResolvedImport{
+				FoundAt: filepath.Join(tmpDir, "app", "local.libsonnet"),
+				RelPath: filepath.Join("app", "local.libsonnet"),
+			},
+		},
+		{
+			name:         "library path",
+			importedPath: "util.libsonnet",
+			want: ResolvedImport{
+				FoundAt: filepath.Join(tmpDir, "lib", "util.libsonnet"),
+				RelPath: filepath.Join("lib", "util.libsonnet"),
+				JPath:   "lib",
+			},
+		},
+		{name: "missing", importedPath: "missing.libsonnet", wantErr: ErrFileNotFound},
+		{name: "traversal", importedPath: "../../x.libsonnet", wantErr: ErrForbiddenRelativePathTraversal},
+		{name: "directory", importedPath: "pkg", wantErr: ErrReadFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := imp.Resolve(from, tt.importedPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_MatchesImport(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "util.libsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	from := filepath.Join(tmpDir, "main.jsonnet")
+	resolved, err := imp.Resolve(from, "util.libsonnet")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	contents, foundAt, err := imp.Import(from, "util.libsonnet")
+	if err != nil {
+		t.Fatalf("Import() after Resolve() error = %v", err)
+	}
+	if foundAt != resolved.FoundAt || contents.String() != `{}` {
+		t.Errorf("Import() = (%q, %q), want (%q, %q)", contents.String(), foundAt, `{}`, resolved.FoundAt)
+	}
+}
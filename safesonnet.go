@@ -1,15 +1,19 @@
 package safesonnet
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/go-jsonnet"
 )
@@ -37,16 +41,60 @@ var (
 	ErrCacheInternalType = errors.New("internal cache error: unexpected type")
 	// ErrForbiddenPathTraversal is returned for generic path traversal attempts.
 	ErrForbiddenPathTraversal = errors.New("forbidden path traversal")
+	// ErrWorldWritable is returned when a file policy forbids world-writable files.
+	ErrWorldWritable = errors.New("file is world-writable")
+	// ErrFileOwner is returned when a file policy forbids the owner of a file.
+	ErrFileOwner = errors.New("file owner is not allowed")
+	// ErrImportRejected is returned when an import hook vetoes an import.
+	ErrImportRejected = errors.New("import rejected by hook")
+	// ErrReadTimeout is returned when reading a file takes longer than the read timeout.
+	ErrReadTimeout = errors.New("file read timed out")
+	// ErrDepfilePath is returned when a path cannot be written to a depfile.
+	ErrDepfilePath = errors.New("path cannot be represented in a depfile")
+	// ErrLimitExceeded is returned when an evaluation exceeds one of its Limits.
+	ErrLimitExceeded = errors.New("evaluation limit exceeded")
+	// ErrInvalidArgument is returned when a native function receives an invalid argument.
+	ErrInvalidArgument = errors.New("invalid native function argument")
+	// ErrWriteFile is returned when an output file cannot be written.
+	ErrWriteFile = errors.New("failed to write file")
+	// ErrUnknownFormat is returned when an output format is not supported.
+	ErrUnknownFormat = errors.New("unknown output format")
+	// ErrVendor is returned when an entrypoint cannot be vendored.
+	ErrVendor = errors.New("cannot vendor import")
+	// ErrJsonnetfile is returned when a jsonnet-bundler jsonnetfile cannot be read.
+	ErrJsonnetfile = errors.New("invalid jsonnetfile")
+	// ErrDependencyNotInstalled is returned when a jsonnet-bundler dependency is missing from the vendor directory.
+	ErrDependencyNotInstalled = errors.New("jsonnet-bundler dependency is not installed")
+	// ErrDependencyOutsideRoot is returned when a jsonnet-bundler dependency resolves outside the root directory.
+	ErrDependencyOutsideRoot = errors.New("jsonnet-bundler dependency is outside root directory")
 )
 
 // SafeImporter implements jsonnet.Importer that restricts imports to a root directory.
 type SafeImporter struct {
-	JPaths      []string
-	root        *os.Root
-	rootAbsPath string
-	cacheMu     sync.RWMutex
-	fsCache     map[string]cacheEntry
-	logger      *log.Logger
+	JPaths           []string
+	root             *os.Root
+	rootAbsPath      string
+	cacheMu          sync.RWMutex
+	fsCache          map[string]cacheEntry
+	hookContents     map[string]jsonnet.Contents
+	logger           *log.Logger
+	filePolicy       *FilePolicy
+	hooks            []Hook
+	auditSink        AuditSink
+	slog             *slog.Logger
+	metrics          Metrics
+	tracer           Tracer
+	graph            *ImportGraph
+	readTimeout      time.Duration
+	bundlerVendorDir string
+}
+
+// fileLookup is the outcome of loading a single candidate file.
+type fileLookup struct {
+	contents jsonnet.Contents
+	foundAt  string
+	found    bool
+	cached   bool
 }
 
 type cacheEntry struct {
@@ -92,16 +140,28 @@ func NewSafeImporter(rootDir string, jpaths []string, opts ...Option) (*SafeImpo
 	}
 
 	si := &SafeImporter{
-		JPaths:      cleanJPaths,
-		root:        root,
-		rootAbsPath: rootAbs,
-		fsCache:     make(map[string]cacheEntry),
-		logger:      log.New(io.Discard, "", 0),
+		JPaths:       cleanJPaths,
+		root:         root,
+		rootAbsPath:  rootAbs,
+		fsCache:      make(map[string]cacheEntry),
+		hookContents: make(map[string]jsonnet.Contents),
+		logger:       log.New(io.Discard, "", 0),
+		slog:         slog.New(slog.DiscardHandler),
+		metrics:      nopMetrics{},
+		tracer:       nopTracer{},
 	}
 	for _, o := range opts {
 		o(si)
 	}
 
+	if si.bundlerVendorDir != "" {
+		if err := si.configureBundler(); err != nil {
+			root.Close()
+
+			return nil, err
+		}
+	}
+
 	return si, nil
 }
 
@@ -152,57 +212,124 @@ func resolveJPath(jp, rootAbs string) (string, error) {
 }
 
 func (s *SafeImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
-	s.logger.Printf("Import: from=%q path=%q", importedFrom, importedPath)
+	return s.importContext(context.Background(), importedFrom, importedPath)
+}
 
-	if strings.Contains(importedPath, "\x00") {
-		return jsonnet.Contents{}, "", ErrInvalidNullByte
+func (s *SafeImporter) importContext(
+	ctx context.Context,
+	importedFrom, importedPath string,
+) (jsonnet.Contents, string, error) {
+	s.logger.Printf("Import: from=%q path=%q", importedFrom, importedPath)
+	start := time.Now()
+	ctx, span := s.tracer.Start(ctx, SpanImport)
+	span.SetAttribute(AttrImportedFrom, importedFrom)
+	span.SetAttribute(AttrImportedPath, importedPath)
+
+	var (
+		contents jsonnet.Contents
+		foundAt  string
+		err      error
+	)
+	r := newResolution(importedFrom, importedPath)
+	if len(s.hooks) > 0 {
+		contents, foundAt, err = s.importWithHooks(ctx, r)
+	} else {
+		contents, foundAt, err = s.resolve(ctx, r)
 	}
-	if strings.Contains(importedFrom, "\x00") {
-		return jsonnet.Contents{}, "", fmt.Errorf("%w: importedFrom %q", ErrInvalidNullByte, importedFrom)
+	if err == nil {
+		span.SetAttribute(AttrFoundAt, foundAt)
+		if s.graph != nil {
+			s.graph.record(importedFrom, foundAt)
+		}
+	}
+	span.End(err)
+	s.logImport(ctx, importedFrom, importedPath, foundAt, r.cached(), start, err)
+	s.measureImport(start, err)
+
+	return contents, foundAt, err
+}
+
+func (s *SafeImporter) resolve(ctx context.Context, r *resolution) (jsonnet.Contents, string, error) {
+	if err := s.validatePaths(r.importedFrom, r.importedPath); err != nil {
+		return jsonnet.Contents{}, "", err
 	}
 
-	contents, foundAt, found, err := s.tryPrimaryImport(importedFrom, importedPath)
+	res, err := s.tryPrimaryImport(ctx, r)
 	if err != nil {
 		return jsonnet.Contents{}, "", err
 	}
-	if found {
-		return contents, foundAt, nil
+	if res.found {
+		return res.contents, res.foundAt, nil
+	}
+
+	return s.searchJPaths(ctx, r)
+}
+
+func (s *SafeImporter) validatePaths(importedFrom, importedPath string) error {
+	if !strings.Contains(importedPath, "\x00") && !strings.Contains(importedFrom, "\x00") {
+		return nil
+	}
+
+	err := &ImportError{
+		Op:           OpValidate,
+		ImportedFrom: importedFrom,
+		ImportedPath: importedPath,
+		Err:          ErrInvalidNullByte,
 	}
 
-	return s.searchJPaths(importedFrom, importedPath)
+	s.audit(AuditEvent{
+		Stage:        AuditStageImport,
+		ImportedFrom: importedFrom,
+		ImportedPath: importedPath,
+		Decision:     AuditDeny,
+		Err:          err,
+	})
+
+	return err
 }
 
-func (s *SafeImporter) tryPrimaryImport(importedFrom, importedPath string) (jsonnet.Contents, string, bool, error) {
-	primaryCandidate, isAbsImport, err := s.resolveImportPath(importedFrom, importedPath)
+func (s *SafeImporter) tryPrimaryImport(ctx context.Context, r *resolution) (fileLookup, error) {
+	primaryCandidate, isAbsImport, err := s.resolveImportPath(r.importedFrom, r.importedPath)
 	if err != nil {
-		return jsonnet.Contents{}, "", false, err
+		return fileLookup{}, r.fail(OpResolve, "", "", err)
 	}
 
 	rel, inside, err := relToRoot(s.rootAbsPath, primaryCandidate)
 	if err != nil {
-		return jsonnet.Contents{}, "", false, err
+		return fileLookup{}, r.fail(OpResolve, primaryCandidate, "", err)
 	}
 	if !inside {
-		if isAbsImport {
-			return jsonnet.Contents{}, "", false, fmt.Errorf(
-				"%w: path %q (resolved to %q) is outside root directory %q",
-				ErrForbiddenAbsolutePath, importedPath, primaryCandidate, s.rootAbsPath)
-		}
-		if importedFrom != "" {
-			return jsonnet.Contents{}, "", false, fmt.Errorf(
-				"%w: path %q (in search dir %q, resolved to %q) would be outside root directory %q",
-				ErrForbiddenRelativePathTraversal,
-				importedPath,
-				filepath.Dir(importedFrom),
-				primaryCandidate,
-				s.rootAbsPath,
-			)
+		err := s.primaryOutsideRoot(r.importedFrom, isAbsImport)
+		r.add(Candidate{Path: primaryCandidate, Outcome: CandidateOutsideRoot, Err: err})
+		if err != nil {
+			err = r.fail(OpResolve, primaryCandidate, "", err)
 		}
+		s.auditOutsideRoot(AuditStagePrimary, r, primaryCandidate, "", err)
 
-		return jsonnet.Contents{}, "", false, nil
+		return fileLookup{}, err
 	}
 
-	return s.loadFile(primaryCandidate, rel)
+	ctx, span := s.tracer.Start(ctx, SpanPrimary)
+	res, err := s.probe(ctx, span, r, primaryCandidate, rel, "")
+	if err != nil {
+		err = r.fail(OpRead, primaryCandidate, "", err)
+	}
+	s.recordLookup(ctx, AuditStagePrimary, r, primaryCandidate, "", res, err)
+
+	return res, err
+}
+
+// primaryOutsideRoot reports whether a primary candidate outside the root is a
+// violation. Relative entrypoints outside the root fall back to JPath search.
+func (s *SafeImporter) primaryOutsideRoot(importedFrom string, isAbsImport bool) error {
+	if isAbsImport {
+		return fmt.Errorf("%w: outside root directory %q", ErrForbiddenAbsolutePath, s.rootAbsPath)
+	}
+	if importedFrom != "" {
+		return fmt.Errorf("%w: outside root directory %q", ErrForbiddenRelativePathTraversal, s.rootAbsPath)
+	}
+
+	return nil
 }
 
 func (s *SafeImporter) resolveImportPath(importedFrom, importedPath string) (string, bool, error) {
@@ -227,32 +354,53 @@ func (s *SafeImporter) resolveImportPath(importedFrom, importedPath string) (str
 	// Initial import
 	abs, err := filepath.Abs(importedPath)
 	if err != nil {
-		return "", false, fmt.Errorf("error resolving initial path %q to absolute: %w", importedPath, err)
+		return "", false, fmt.Errorf("resolving initial path to absolute: %w", err)
 	}
 
 	return filepath.Clean(abs), false, nil
 }
 
-func (s *SafeImporter) searchJPaths(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
-	for _, jp := range s.searchPaths(importedFrom) {
-		candidate := filepath.Join(s.rootAbsPath, jp, importedPath)
+func (s *SafeImporter) searchJPaths(ctx context.Context, r *resolution) (jsonnet.Contents, string, error) {
+	for _, jp := range s.searchPaths(r.importedFrom) {
+		candidate := filepath.Join(s.rootAbsPath, jp, r.importedPath)
 		candidate = filepath.Clean(candidate)
+		if r.tried(candidate) {
+			continue
+		}
 
 		rel, inside, err := relToRoot(s.rootAbsPath, candidate)
 		if err != nil || !inside {
+			r.add(Candidate{Path: candidate, JPath: jp, Outcome: CandidateOutsideRoot})
+			s.auditOutsideRoot(AuditStageJPath, r, candidate, jp, nil)
+
 			continue
 		}
 
-		contents, foundAt, found, err := s.loadFile(candidate, rel)
+		probeCtx, span := s.tracer.Start(ctx, SpanJPath)
+		res, err := s.probe(probeCtx, span, r, candidate, rel, jp)
+		if err != nil {
+			err = r.fail(OpRead, candidate, jp, err)
+		}
+		s.recordLookup(probeCtx, AuditStageJPath, r, candidate, jp, res, err)
 		if err != nil {
 			return jsonnet.Contents{}, "", err
 		}
-		if found {
-			return contents, foundAt, nil
+		if res.found {
+			return res.contents, res.foundAt, nil
 		}
 	}
 
-	return jsonnet.Contents{}, "", ErrFileNotFound
+	err := r.notFound()
+	err.Suggestions = s.suggest(r)
+	s.audit(AuditEvent{
+		Stage:        AuditStageImport,
+		ImportedFrom: r.importedFrom,
+		ImportedPath: r.importedPath,
+		Decision:     AuditMiss,
+		Err:          err,
+	})
+
+	return jsonnet.Contents{}, "", err
 }
 
 func (s *SafeImporter) searchPaths(importedFrom string) []string {
@@ -270,35 +418,240 @@ func hasDotPath(paths []string) bool {
 	return slices.Contains(paths, ".")
 }
 
-func (s *SafeImporter) loadFile(absPath, relPath string) (jsonnet.Contents, string, bool, error) {
+// probe looks up a single candidate within span and ends it.
+func (s *SafeImporter) probe(
+	ctx context.Context,
+	span Span,
+	r *resolution,
+	absPath, relPath, jpath string,
+) (fileLookup, error) {
+	span.SetAttribute(AttrCandidate, absPath)
+	if jpath != "" {
+		span.SetAttribute(AttrJPath, jpath)
+	}
+	if err := ctx.Err(); err != nil {
+		span.End(err)
+
+		return fileLookup{}, err
+	}
+
+	var (
+		res fileLookup
+		err error
+	)
+	if r.statOnly {
+		res, err = s.statFile(absPath, relPath)
+	} else {
+		res, err = s.loadFile(ctx, absPath, relPath)
+	}
+	span.SetAttribute(AttrCacheHit, res.cached)
+	span.SetAttribute(AttrFound, res.found)
+	span.End(err)
+
+	return res, err
+}
+
+func (s *SafeImporter) loadFile(ctx context.Context, absPath, relPath string) (fileLookup, error) {
 	if entry, ok := s.cached(absPath); ok {
 		return entry.result()
 	}
 
-	f, err := s.root.Open(relPath)
+	ctx, span := s.tracer.Start(ctx, SpanRead)
+	span.SetAttribute(AttrCandidate, relPath)
+	start := time.Now()
+
+	var res fileLookup
+	data, err := s.readFileTimeout(ctx, relPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s.cache(absPath, cacheEntry{err: err})
+		err = nil
+	case err == nil:
+		s.metrics.FileLoaded(len(data), time.Since(start))
+		span.SetAttribute(AttrBytes, len(data))
+
+		res = fileLookup{contents: jsonnet.MakeContents(string(data)), foundAt: absPath, found: true}
+		s.cache(absPath, cacheEntry{contents: res.contents, foundAt: absPath})
+	}
+	span.End(err)
+
+	return res, err
+}
+
+// readFileTimeout is readFileContext bounded by the read timeout, if any.
+func (s *SafeImporter) readFileTimeout(ctx context.Context, relPath string) ([]byte, error) {
+	if s.readTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, s.readTimeout, ErrReadTimeout)
+		defer cancel()
+	}
+
+	return s.readFileContext(ctx, relPath)
+}
+
+// readFileContext reads relPath, returning early with the cause of ctx being
+// done if that happens first. Only complete reads are returned, so an
+// abandoned read never reaches the cache. An abandoned read has its file
+// closed so that a blocked read returns; an open that blocks, such as on a
+// hung network filesystem, cannot be interrupted and holds its goroutine
+// until it returns.
+func (s *SafeImporter) readFileContext(ctx context.Context, relPath string) ([]byte, error) {
+	if ctx.Done() == nil {
+		return s.readFile(relPath)
+	}
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	pending := &pendingRead{}
+	go func() {
+		data, err := s.readPending(relPath, pending)
+		done <- result{data: data, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		pending.abandon()
+
+		return nil, context.Cause(ctx)
+	}
+}
+
+// pendingRead is a read that the waiting side of readFileContext may abandon.
+type pendingRead struct {
+	mu        sync.Mutex
+	f         *os.File
+	abandoned bool
+}
+
+// opened records f and reports whether the read should continue.
+func (p *pendingRead) opened(f *os.File) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.f = f
+
+	return !p.abandoned
+}
+
+// abandon closes the file being read, if it has been opened.
+func (p *pendingRead) abandon() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.abandoned = true
+	if p.f != nil {
+		p.f.Close()
+	}
+}
+
+// readFile opens, checks and reads relPath. A missing file is reported with an
+// error matching fs.ErrNotExist.
+func (s *SafeImporter) readFile(relPath string) ([]byte, error) {
+	return s.readPending(relPath, nil)
+}
+
+// readPending is readFile for a read that pending, when not nil, may abandon.
+// Only regular files are opened, since opening or reading a FIFO or device
+// can block indefinitely.
+func (s *SafeImporter) readPending(relPath string, pending *pendingRead) ([]byte, error) {
+	fi, err := s.root.Stat(relPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			s.cache(absPath, cacheEntry{err: err})
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+	if err := checkRegular(fi, relPath); err != nil {
+		return nil, err
+	}
 
-			return jsonnet.Contents{}, "", false, nil
+	f, err := s.root.Open(relPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
 		}
 
-		return jsonnet.Contents{}, "", false, fmt.Errorf("%w: %q: %w", ErrReadFile, relPath, err)
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
 	}
 	defer f.Close()
 
+	if pending != nil && !pending.opened(f) {
+		return nil, fmt.Errorf("%w: read abandoned", ErrReadFile)
+	}
+	if err := s.checkFile(f); err != nil {
+		return nil, err
+	}
+
 	data, err := io.ReadAll(f)
 	if err != nil {
-		return jsonnet.Contents{}, "", false, fmt.Errorf("%w: %q: %w", ErrReadFile, relPath, err)
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
 	}
 
-	contents := jsonnet.MakeContents(string(data))
-	s.cache(absPath, cacheEntry{
-		contents: contents,
-		foundAt:  absPath,
-	})
+	return data, nil
+}
+
+func (s *SafeImporter) checkFile(f *os.File) error {
+	if s.filePolicy == nil {
+		return nil
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+
+	return s.filePolicy.check(fi)
+}
+
+// checkRegular rejects anything but a regular file, such as a directory, FIFO
+// or device.
+func checkRegular(fi fs.FileInfo, relPath string) error {
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%w: %q is not a regular file", ErrReadFile, relPath)
+	}
+
+	return nil
+}
+
+// statFile is the metadata-only counterpart of loadFile used by Resolve. It
+// shares the cache but only records missing files, since it reads no contents.
+func (s *SafeImporter) statFile(absPath, relPath string) (fileLookup, error) {
+	if entry, ok := s.cached(absPath); ok {
+		res, err := entry.result()
+		res.contents = jsonnet.Contents{}
+
+		return res, err
+	}
+
+	fi, err := s.root.Stat(relPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.cache(absPath, cacheEntry{err: err})
+
+			return fileLookup{}, nil
+		}
+
+		return fileLookup{}, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+	if err := checkRegular(fi, relPath); err != nil {
+		return fileLookup{}, err
+	}
+	if s.filePolicy != nil {
+		if err := s.filePolicy.check(fi); err != nil {
+			return fileLookup{}, err
+		}
+	}
 
-	return contents, absPath, true, nil
+	return fileLookup{foundAt: absPath, found: true}, nil
 }
 
 func (s *SafeImporter) cached(absPath string) (cacheEntry, bool) {
@@ -317,16 +670,16 @@ func (s *SafeImporter) cache(absPath string, entry cacheEntry) {
 	s.fsCache[absPath] = entry
 }
 
-func (e cacheEntry) result() (jsonnet.Contents, string, bool, error) {
+func (e cacheEntry) result() (fileLookup, error) {
 	if e.err != nil {
 		if os.IsNotExist(e.err) {
-			return jsonnet.Contents{}, "", false, nil
+			return fileLookup{cached: true}, nil
 		}
 
-		return jsonnet.Contents{}, "", false, e.err
+		return fileLookup{cached: true}, e.err
 	}
 
-	return e.contents, e.foundAt, true, nil
+	return fileLookup{contents: e.contents, foundAt: e.foundAt, found: true, cached: true}, nil
 }
 
 func relToRoot(rootAbs, absPath string) (string, bool, error) {
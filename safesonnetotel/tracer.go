@@ -0,0 +1,67 @@
+// Package safesonnetotel adapts safesonnet.Tracer to OpenTelemetry.
+package safesonnetotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thevilledev/safesonnet/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope used for spans.
+const ScopeName = "github.com/thevilledev/safesonnet/v2"
+
+// Tracer implements safesonnet.Tracer on top of an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ safesonnet.Tracer = (*Tracer)(nil)
+
+// New returns a Tracer creating spans from tp.
+func New(tp trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: tp.Tracer(ScopeName)}
+}
+
+// Start implements safesonnet.Tracer.
+//
+//nolint:ireturn // safesonnet.Tracer returns the Span interface.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, safesonnet.Span) {
+	ctx, s := t.tracer.Start(ctx, name)
+
+	return ctx, span{s}
+}
+
+type span struct {
+	trace.Span
+}
+
+func (s span) SetAttribute(key string, value any) {
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+	case int64:
+		kv = attribute.Int64(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+
+	s.SetAttributes(kv)
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+
+	s.Span.End()
+}
@@ -0,0 +1,74 @@
+package safesonnetotel_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thevilledev/safesonnet/v2"
+	"github.com/thevilledev/safesonnet/v2/safesonnetotel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "lib"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "lib", "util.libsonnet"), []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	imp, err := safesonnet.NewSafeImporter(tmpDir, []string{"lib"},
+		safesonnet.WithTracer(safesonnetotel.New(tp)))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	if _, _, err := imp.Import(filepath.Join(tmpDir, "main.jsonnet"), "util.libsonnet"); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	spans := rec.Ended()
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	want := []string{
+		safesonnet.SpanRead, safesonnet.SpanPrimary,
+		safesonnet.SpanRead, safesonnet.SpanJPath,
+		safesonnet.SpanImport,
+	}
+	if len(names) != len(want) {
+		t.Fatalf("ended spans = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("ended spans = %v, want %v", names, want)
+		}
+	}
+
+	root := spans[len(spans)-1]
+	for _, s := range spans[:len(spans)-1] {
+		if s.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("span %s is not in the import trace", s.Name())
+		}
+	}
+
+	jpath := spans[3]
+	attrs := map[string]string{}
+	for _, kv := range jpath.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[safesonnet.AttrJPath] != "lib" || attrs[safesonnet.AttrFound] != "true" ||
+		attrs[safesonnet.AttrCacheHit] != "false" {
+		t.Errorf("jpath span attributes = %v", attrs)
+	}
+}
@@ -0,0 +1,103 @@
+// Package safesonnetprom adapts safesonnet.Metrics to Prometheus collectors.
+package safesonnetprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thevilledev/safesonnet/v2"
+)
+
+const namespace = "safesonnet"
+
+// Metrics implements safesonnet.Metrics with Prometheus collectors.
+type Metrics struct {
+	imports        *prometheus.CounterVec
+	importDuration prometheus.Histogram
+	denied         *prometheus.CounterVec
+	cacheLookups   *prometheus.CounterVec
+	bytesRead      prometheus.Counter
+	loadDuration   prometheus.Histogram
+}
+
+var _ safesonnet.Metrics = (*Metrics)(nil)
+
+// New creates the collectors and registers them with reg.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		imports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "imports_total",
+			Help:      "Number of Import calls by outcome.",
+		}, []string{"outcome"}),
+		importDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "import_duration_seconds",
+			Help:      "Duration of Import calls, including resolution and reads.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "denied_imports_total",
+			Help:      "Number of denied imports by reason.",
+		}, []string{"reason"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Number of candidate file lookups by cache result.",
+		}, []string{"result"}),
+		bytesRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "read_bytes_total",
+			Help:      "Number of bytes read from imported files.",
+		}),
+		loadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "file_load_duration_seconds",
+			Help:      "Time taken to open, check and read an imported file.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.imports, m.importDuration, m.denied, m.cacheLookups, m.bytesRead, m.loadDuration,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ImportCompleted implements safesonnet.Metrics.
+func (m *Metrics) ImportCompleted(outcome safesonnet.ImportOutcome, d time.Duration) {
+	m.imports.WithLabelValues(string(outcome)).Inc()
+	m.importDuration.Observe(d.Seconds())
+}
+
+// ImportDenied implements safesonnet.Metrics.
+func (m *Metrics) ImportDenied(sentinel error) {
+	reason := "unknown"
+	if sentinel != nil {
+		reason = sentinel.Error()
+	}
+
+	m.denied.WithLabelValues(reason).Inc()
+}
+
+// CacheLookup implements safesonnet.Metrics.
+func (m *Metrics) CacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+// FileLoaded implements safesonnet.Metrics.
+func (m *Metrics) FileLoaded(bytes int, d time.Duration) {
+	m.bytesRead.Add(float64(bytes))
+	m.loadDuration.Observe(d.Seconds())
+}
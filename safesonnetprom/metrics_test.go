@@ -0,0 +1,70 @@
+package safesonnetprom_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thevilledev/safesonnet/v2"
+	"github.com/thevilledev/safesonnet/v2/safesonnetprom"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mainFile := filepath.Join(tmpDir, "main.jsonnet")
+	if err := os.WriteFile(mainFile, []byte(`{x: 1}`), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	m, err := safesonnetprom.New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	imp, err := safesonnet.NewSafeImporter(tmpDir, nil, safesonnet.WithMetrics(m))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	for range 2 {
+		if _, _, err := imp.Import("", mainFile); err != nil {
+			t.Fatalf("Import() error = %v", err)
+		}
+	}
+	if _, _, err := imp.Import(mainFile, "../escape.jsonnet"); err == nil {
+		t.Fatal("Import() traversal should fail")
+	}
+
+	expected := `
+# HELP safesonnet_imports_total Number of Import calls by outcome.
+# TYPE safesonnet_imports_total counter
+safesonnet_imports_total{outcome="denied"} 1
+safesonnet_imports_total{outcome="ok"} 2
+# HELP safesonnet_denied_imports_total Number of denied imports by reason.
+# TYPE safesonnet_denied_imports_total counter
+safesonnet_denied_imports_total{reason="forbidden relative import path traversal"} 1
+# HELP safesonnet_cache_lookups_total Number of candidate file lookups by cache result.
+# TYPE safesonnet_cache_lookups_total counter
+safesonnet_cache_lookups_total{result="hit"} 1
+safesonnet_cache_lookups_total{result="miss"} 1
+# HELP safesonnet_read_bytes_total Number of bytes read from imported files.
+# TYPE safesonnet_read_bytes_total counter
+safesonnet_read_bytes_total 6
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"safesonnet_imports_total",
+		"safesonnet_denied_imports_total",
+		"safesonnet_cache_lookups_total",
+		"safesonnet_read_bytes_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,194 @@
+package safesonnet
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/google/go-jsonnet/toolutils"
+)
+
+// ImportKind is the kind of an import expression.
+type ImportKind string
+
+const (
+	// ImportCode is an import expression, whose target is Jsonnet code.
+	ImportCode ImportKind = "import"
+	// ImportString is an importstr expression.
+	ImportString ImportKind = "importstr"
+	// ImportBinary is an importbin expression.
+	ImportBinary ImportKind = "importbin"
+)
+
+// StaticImport is an import expression found by ScanImports.
+type StaticImport struct {
+	// Kind is the kind of import expression.
+	Kind ImportKind
+	// Path is the literal import path.
+	Path string
+	// From is the file containing the expression, relative to the root.
+	From string
+	// Line and Column locate the expression in From, starting at 1.
+	Line   int
+	Column int
+	// Resolved is where the import resolves to. It is zero when Err is set
+	// by resolution.
+	Resolved ResolvedImport
+	// Err is the resolution error, or the error parsing the imported file.
+	Err error
+
+	// literal locates the path string literal, for rewriting it.
+	literal ast.LocationRange
+}
+
+// ScanResult is the static import graph of an entrypoint.
+type ScanResult struct {
+	// Graph holds an edge for every resolved import, and one from "" to the
+	// entrypoint.
+	Graph *ImportGraph
+	// Imports lists every import expression, depth first in source order.
+	// Files imported more than once are scanned once.
+	Imports []StaticImport
+	// Violations lists the imports rejected as sandbox or policy violations.
+	Violations []StaticImport
+	// Unresolved lists the other imports that failed, such as missing files
+	// and imported files with syntax errors.
+	Unresolved []StaticImport
+}
+
+// ScanImports parses entrypoint and, recursively, the Jsonnet files it
+// imports, resolving every import, importstr and importbin expression through
+// importer. Jsonnet code is never evaluated, so imports in branches that
+// evaluation would skip are reported too. Hooks are not run: imports resolve
+// and files load as they are on disk, under the root, JPaths and file policy.
+// Only the entrypoint failing to load or parse is returned as an error.
+func ScanImports(importer *SafeImporter, entrypoint string) (*ScanResult, error) {
+	contents, foundAt, err := importer.loadStatic("", entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &scanner{
+		s:    importer,
+		res:  &ScanResult{Graph: NewImportGraph()},
+		seen: make(map[string]struct{}),
+	}
+	sc.res.Graph.root = importer.rootAbsPath
+	sc.res.Graph.record("", foundAt)
+	if err := sc.scan(foundAt, contents); err != nil {
+		return nil, err
+	}
+
+	for _, si := range sc.res.Imports {
+		switch {
+		case si.Err == nil:
+		case IsDenied(si.Err):
+			sc.res.Violations = append(sc.res.Violations, si)
+		default:
+			sc.res.Unresolved = append(sc.res.Unresolved, si)
+		}
+	}
+
+	return sc.res, nil
+}
+
+type scanner struct {
+	s    *SafeImporter
+	res  *ScanResult
+	seen map[string]struct{}
+}
+
+// scan parses a file and resolves its imports.
+func (sc *scanner) scan(file string, contents jsonnet.Contents) error {
+	sc.seen[file] = struct{}{}
+
+	node, err := jsonnet.SnippetToAST(file, contents.String())
+	if err != nil {
+		return err
+	}
+
+	from := sc.res.Graph.rel(file)
+	for _, si := range literalImports(node) {
+		si.From = from
+		sc.resolve(file, si)
+	}
+
+	return nil
+}
+
+func (sc *scanner) resolve(file string, si StaticImport) {
+	i := len(sc.res.Imports)
+	sc.res.Imports = append(sc.res.Imports, si)
+
+	ri, err := sc.s.Resolve(file, si.Path)
+	if err != nil {
+		sc.res.Imports[i].Err = err
+
+		return
+	}
+	sc.res.Imports[i].Resolved = ri
+	sc.res.Graph.record(file, ri.FoundAt)
+
+	if si.Kind != ImportCode {
+		return
+	}
+	if _, ok := sc.seen[ri.FoundAt]; ok {
+		return
+	}
+
+	contents, foundAt, err := sc.s.loadStatic(file, si.Path)
+	if err == nil {
+		err = sc.scan(foundAt, contents)
+	}
+	if err != nil {
+		sc.res.Imports[i].Err = err
+	}
+}
+
+// loadStatic loads an import like Import, but without hooks, logging or
+// recording it in the importer's ImportGraph, so that static analysis matches
+// Resolve.
+func (s *SafeImporter) loadStatic(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	return s.resolve(context.Background(), newResolution(importedFrom, importedPath))
+}
+
+// literalImports returns the import expressions in node in source order.
+// Jsonnet only allows string literals as import paths, so every import is
+// found.
+func literalImports(node ast.Node) []StaticImport {
+	var imports []StaticImport
+	var walk func(ast.Node)
+	walk = func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Import:
+			imports = append(imports, newStaticImport(ImportCode, n.File, n.Loc()))
+		case *ast.ImportStr:
+			imports = append(imports, newStaticImport(ImportString, n.File, n.Loc()))
+		case *ast.ImportBin:
+			imports = append(imports, newStaticImport(ImportBinary, n.File, n.Loc()))
+		}
+		for _, c := range toolutils.Children(n) {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	// Desugaring can reorder nodes, so restore source order.
+	slices.SortStableFunc(imports, func(a, b StaticImport) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	return imports
+}
+
+func newStaticImport(kind ImportKind, file *ast.LiteralString, loc *ast.LocationRange) StaticImport {
+	return StaticImport{
+		Kind:    kind,
+		Path:    file.Value,
+		Line:    loc.Begin.Line,
+		Column:  loc.Begin.Column,
+		literal: *file.Loc(),
+	}
+}
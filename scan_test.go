@@ -0,0 +1,161 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+)
+
+func TestScanImports(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `local lib = import 'lib.libsonnet';
+{
+  lib: lib,
+  data: importstr 'data/config.txt',
+  // Never evaluated, but scanned.
+  unused:: if false then import 'missing.libsonnet' else importbin '../outside.bin',
+}
+`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "lib.libsonnet"), `{ util: import 'util.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "util.libsonnet"), `{ lib: import 'lib.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "data", "config.txt"), `config`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"vendor"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	res, err := ScanImports(imp, filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("ScanImports() error = %v", err)
+	}
+
+	type imported struct {
+		kind   ImportKind
+		path   string
+		from   string
+		line   int
+		relTo  string
+		jpath  string
+		failed bool
+	}
+	var got []imported
+	for _, si := range res.Imports {
+		got = append(got, imported{
+			kind: si.Kind, path: si.Path, from: si.From, line: si.Line,
+			relTo: si.Resolved.RelPath, jpath: si.Resolved.JPath, failed: si.Err != nil,
+		})
+	}
+	want := []imported{
+		{kind: ImportCode, path: "lib.libsonnet", from: "main.jsonnet", line: 1,
+			relTo: filepath.Join("vendor", "lib.libsonnet"), jpath: "vendor"},
+		{kind: ImportCode, path: "util.libsonnet", from: filepath.Join("vendor", "lib.libsonnet"), line: 1,
+			relTo: filepath.Join("vendor", "util.libsonnet")},
+		{kind: ImportCode, path: "lib.libsonnet", from: filepath.Join("vendor", "util.libsonnet"), line: 1,
+			relTo: filepath.Join("vendor", "lib.libsonnet")},
+		{kind: ImportString, path: "data/config.txt", from: "main.jsonnet", line: 4,
+			relTo: filepath.Join("data", "config.txt")},
+		{kind: ImportCode, path: "missing.libsonnet", from: "main.jsonnet", line: 6, failed: true},
+		{kind: ImportBinary, path: "../outside.bin", from: "main.jsonnet", line: 6, failed: true},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Imports =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if len(res.Violations) != 1 || !errors.Is(res.Violations[0].Err, ErrForbiddenRelativePathTraversal) {
+		t.Errorf("Violations = %+v, want the traversal", res.Violations)
+	}
+	if len(res.Unresolved) != 1 || !errors.Is(res.Unresolved[0].Err, ErrFileNotFound) {
+		t.Errorf("Unresolved = %+v, want the missing file", res.Unresolved)
+	}
+
+	wantFiles := []string{
+		filepath.Join("data", "config.txt"),
+		"main.jsonnet",
+		filepath.Join("vendor", "lib.libsonnet"),
+		filepath.Join("vendor", "util.libsonnet"),
+	}
+	if files := res.Graph.Files(); !slices.Equal(files, wantFiles) {
+		t.Errorf("Graph.Files() = %v, want %v", files, wantFiles)
+	}
+}
+
+func TestScanImports_Errors(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "syntax.jsonnet"), `{ a: `)
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `import 'syntax.jsonnet'`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	if _, err := ScanImports(imp, filepath.Join(tmpDir, "syntax.jsonnet")); err == nil {
+		t.Error("ScanImports() should fail for an entrypoint with a syntax error")
+	}
+	if _, err := ScanImports(imp, "/outside.jsonnet"); !errors.Is(err, ErrForbiddenAbsolutePath) {
+		t.Errorf("ScanImports() error = %v, want ErrForbiddenAbsolutePath", err)
+	}
+
+	res, err := ScanImports(imp, filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("ScanImports() error = %v", err)
+	}
+	if len(res.Unresolved) != 1 || res.Unresolved[0].Resolved.RelPath != "syntax.jsonnet" {
+		t.Errorf("Unresolved = %+v, want the file with a syntax error", res.Unresolved)
+	}
+}
+
+func TestScanImports_IgnoresHooks(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `import 'lib.jsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib.jsonnet"), `{}`)
+
+	reject := Hook{BeforeResolve: func(ev *HookEvent) error {
+		if ev.ImportedFrom != "" {
+			return errors.New("rejected")
+		}
+
+		return nil
+	}}
+	inject := Hook{AfterLoad: func(ev *HookEvent) error {
+		ev.Contents = jsonnet.MakeContents(`import 'missing.jsonnet'`)
+
+		return nil
+	}}
+	imp, err := NewSafeImporter(tmpDir, nil, WithHooks(reject, inject))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	res, err := ScanImports(imp, filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("ScanImports() error = %v", err)
+	}
+	if len(res.Imports) != 1 || res.Imports[0].Resolved.RelPath != "lib.jsonnet" || res.Imports[0].Err != nil {
+		t.Errorf("Imports = %+v, want only lib.jsonnet resolved", res.Imports)
+	}
+
+	b, err := Vendor(imp, filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("Vendor() error = %v", err)
+	}
+	for _, f := range b.Files {
+		if strings.Contains(string(f.Data), "missing.jsonnet") {
+			t.Errorf("bundled %s = %q, want the file on disk", f.Path, f.Data)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package safesonnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultStreamSuffix is the file name suffix WriteJSONStream uses unless
+// StreamOptions.Suffix is set.
+const DefaultStreamSuffix = ".json"
+
+// StreamOptions controls how the documents of jsonnet.VM.EvaluateFileStream
+// are written. The zero value writes documents as the VM formatted them.
+type StreamOptions struct {
+	// Prefix and Suffix name the files written by WriteJSONStream as
+	// Prefix + index + Suffix. Indexes start at 0 and are zero-padded to the
+	// width of the largest index, so the names sort in document order. Suffix
+	// defaults to DefaultStreamSuffix.
+	Prefix string
+	Suffix string
+	// Indent re-indents JSON documents with this many spaces. Zero keeps the
+	// VM's formatting.
+	Indent int
+	// DocumentEnd ends a YAML stream with a "..." marker, as jsonnet -y does.
+	DocumentEnd bool
+}
+
+// WriteYAMLStream converts docs to YAML and writes them to name as a single
+// multi-document stream, each document starting with "---". Object keys are
+// sorted, so the output is deterministic.
+func (w *OutputWriter) WriteYAMLStream(name string, docs []string, opts StreamOptions) error {
+	rel, err := outputPath(name)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		y, err := yaml.JSONToYAML([]byte(doc))
+		if err != nil {
+			return fmt.Errorf("%w %q: document %d: %w", ErrWriteFile, rel, i, err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(y)
+	}
+	if opts.DocumentEnd && len(docs) > 0 {
+		buf.WriteString("...\n")
+	}
+
+	return w.put(rel, buf.Bytes())
+}
+
+// WriteJSONStream writes each of docs to its own numbered file and returns the
+// paths written relative to the output directory, in document order. All
+// names are validated before anything is written.
+func (w *OutputWriter) WriteJSONStream(docs []string, opts StreamOptions) ([]string, error) {
+	suffix := opts.Suffix
+	if suffix == "" {
+		suffix = DefaultStreamSuffix
+	}
+	width := len(strconv.Itoa(max(len(docs)-1, 0)))
+
+	paths := make([]string, 0, len(docs))
+	for i := range docs {
+		rel, err := outputPath(fmt.Sprintf("%s%0*d%s", opts.Prefix, width, i, suffix))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, rel)
+	}
+
+	for i, doc := range docs {
+		data, err := indentJSON([]byte(doc), opts.Indent)
+		if err != nil {
+			return paths[:i], fmt.Errorf("%w %q: %w", ErrWriteFile, paths[i], err)
+		}
+		if err := w.put(paths[i], data); err != nil {
+			return paths[:i], err
+		}
+	}
+
+	return paths, nil
+}
+
+func indentJSON(data []byte, indent int) ([]byte, error) {
+	if indent <= 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", strings.Repeat(" ", indent)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,129 @@
+package safesonnet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func evaluateStream(t *testing.T, n int) []string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`[{ i: i, b: 'x' } for i in std.range(0, `+strconv.Itoa(n-1)+`)]`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	docs, err := NewVM(imp, VMOptions{}).EvaluateFileStream(filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFileStream() error = %v", err)
+	}
+
+	return docs
+}
+
+func TestOutputWriter_WriteYAMLStream(t *testing.T) {
+	t.Parallel()
+
+	docs := evaluateStream(t, 2)
+
+	tests := []struct {
+		name string
+		opts StreamOptions
+		want string
+	}{
+		{
+			name: "default",
+			want: "---\nb: x\ni: 0\n---\nb: x\ni: 1\n",
+		},
+		{
+			name: "document end",
+			opts: StreamOptions{DocumentEnd: true},
+			want: "---\nb: x\ni: 0\n---\nb: x\ni: 1\n...\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			outDir := t.TempDir()
+			w, err := NewOutputWriter(outDir)
+			if err != nil {
+				t.Fatalf("NewOutputWriter() error = %v", err)
+			}
+			t.Cleanup(func() { w.Close() })
+
+			if err := w.WriteYAMLStream("out/stream.yaml", docs, tt.opts); err != nil {
+				t.Fatalf("WriteYAMLStream() error = %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(outDir, "out", "stream.yaml"))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("stream = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	w, err := NewOutputWriter(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	err = w.WriteYAMLStream("../stream.yaml", docs, StreamOptions{})
+	if !errors.Is(err, ErrForbiddenRelativePathTraversal) {
+		t.Errorf("WriteYAMLStream() error = %v, want ErrForbiddenRelativePathTraversal", err)
+	}
+}
+
+func TestOutputWriter_WriteJSONStream(t *testing.T) {
+	t.Parallel()
+
+	docs := evaluateStream(t, 11)
+
+	outDir := t.TempDir()
+	w, err := NewOutputWriter(outDir)
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	paths, err := w.WriteJSONStream(docs, StreamOptions{Prefix: "docs/doc-", Indent: 2})
+	if err != nil {
+		t.Fatalf("WriteJSONStream() error = %v", err)
+	}
+
+	// Indexes are padded to the width of the largest, 10.
+	var want []string
+	for i := range 11 {
+		want = append(want, filepath.Join("docs", fmt.Sprintf("doc-%02d.json", i)))
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("WriteJSONStream() = %v, want %v", paths, want)
+	}
+
+	got, err := os.ReadFile(filepath.Join(outDir, "docs", "doc-00.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "{\n  \"b\": \"x\",\n  \"i\": 0\n}\n"; string(got) != want {
+		t.Errorf("doc-00.json = %q, want %q", got, want)
+	}
+
+	_, err = w.WriteJSONStream(docs, StreamOptions{Prefix: "../doc-"})
+	if !errors.Is(err, ErrForbiddenRelativePathTraversal) {
+		t.Errorf("WriteJSONStream() error = %v, want ErrForbiddenRelativePathTraversal", err)
+	}
+}
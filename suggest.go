@@ -0,0 +1,143 @@
+package safesonnet
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxSuggestions caps the number of "did you mean" suggestions.
+	maxSuggestions = 3
+	// maxSuggestionDistance is the largest edit distance still suggested.
+	maxSuggestionDistance = 2
+)
+
+// suggestion is a near-miss file name and how far it is from the request.
+type suggestion struct {
+	path     string
+	distance int
+}
+
+// suggest lists import paths close to the one that was not found, based on
+// the contents of each directory searched inside the root.
+func (s *SafeImporter) suggest(r *resolution) []string {
+	want := filepath.Base(r.importedPath)
+	prefix := filepath.Dir(r.importedPath)
+
+	var found []suggestion
+	seen := make(map[string]struct{})
+	for _, c := range r.candidates {
+		if c.Outcome != CandidateNotFound {
+			continue
+		}
+
+		for _, name := range s.listDir(filepath.Dir(c.Path)) {
+			d, ok := nameDistance(want, name)
+			if !ok {
+				continue
+			}
+
+			path := filepath.Join(prefix, name)
+			if _, dup := seen[path]; dup {
+				continue
+			}
+			seen[path] = struct{}{}
+			found = append(found, suggestion{path: path, distance: d})
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b suggestion) int {
+		return cmp.Compare(a.distance, b.distance)
+	})
+
+	paths := make([]string, 0, min(len(found), maxSuggestions))
+	for _, sg := range found[:min(len(found), maxSuggestions)] {
+		paths = append(paths, sg.path)
+	}
+
+	return paths
+}
+
+// listDir returns the file names in the directory at absDir, or nil when it
+// is outside the root or cannot be read.
+func (s *SafeImporter) listDir(absDir string) []string {
+	rel, inside, err := relToRoot(s.rootAbsPath, absDir)
+	if err != nil || !inside {
+		return nil
+	}
+
+	d, err := s.root.Open(rel)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+
+	entries, err := d.ReadDir(-1)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names
+}
+
+// nameDistance reports how close name is to want. Case-only differences and
+// swapped .jsonnet/.libsonnet extensions rank above plain typos.
+func nameDistance(want, name string) (int, bool) {
+	switch {
+	case want == name:
+		return 0, false
+	case strings.EqualFold(want, name):
+		return 0, true
+	case swappedExtension(want, name):
+		return 0, true
+	}
+
+	d := levenshtein(want, name)
+	if d > maxSuggestionDistance || d >= len(want) {
+		return 0, false
+	}
+
+	return d, true
+}
+
+func swappedExtension(want, name string) bool {
+	exts := []string{".jsonnet", ".libsonnet"}
+	wantExt, nameExt := filepath.Ext(want), filepath.Ext(name)
+	if wantExt == nameExt || !slices.Contains(exts, wantExt) || !slices.Contains(exts, nameExt) {
+		return false
+	}
+
+	return strings.TrimSuffix(want, wantExt) == strings.TrimSuffix(name, nameExt)
+}
+
+// levenshtein returns the edit distance between a and b in bytes.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,74 @@
+package safesonnet
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestImport_Suggestions(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "utils.libsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "Config.jsonnet"), `{}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "k8s.jsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	tests := []struct {
+		name         string
+		importedPath string
+		want         []string
+	}{
+		{name: "typo", importedPath: "utlis.libsonnet", want: []string{"utils.libsonnet"}},
+		{name: "case only", importedPath: "config.jsonnet", want: []string{"Config.jsonnet"}},
+		{name: "wrong extension", importedPath: "k8s.libsonnet", want: []string{"k8s.jsonnet"}},
+		{name: "keeps directory", importedPath: "lib/utils.jsonnet", want: []string{filepath.Join("lib", "utils.libsonnet")}},
+		{name: "nothing close", importedPath: "unrelated.jsonnet", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := imp.Import(filepath.Join(tmpDir, "main.jsonnet"), tt.importedPath)
+			var ie *ImportError
+			if !errors.As(err, &ie) || !errors.Is(err, ErrFileNotFound) {
+				t.Fatalf("Import() error = %v, want not-found ImportError", err)
+			}
+			if !slices.Equal(ie.Suggestions, tt.want) {
+				t.Errorf("Suggestions = %v, want %v", ie.Suggestions, tt.want)
+			}
+			if len(tt.want) > 0 && !strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("Import() error %q has no suggestion", err)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"utils", "utlis", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package safesonnet
+
+import "time"
+
+// WithReadTimeout bounds the time taken to open, check and read each file.
+// A read that exceeds d fails with ErrReadTimeout and is not cached, so a
+// later import retries it. Zero disables the timeout.
+//
+// Only regular files are imported, so FIFOs and devices, which can block
+// forever, are rejected before they are opened. A read that times out has its
+// file closed. Opening a file cannot be interrupted, so an open that hangs, or
+// a read that ignores the close, as on a hung network filesystem, keeps its
+// goroutine and file descriptor until the operating system call returns.
+func WithReadTimeout(d time.Duration) Option {
+	return func(s *SafeImporter) {
+		if d >= 0 {
+			s.readTimeout = d
+		}
+	}
+}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package safesonnet
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestImport_RejectsSpecialFiles(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	// Opening a FIFO without a writer blocks, so it must never be opened.
+	fifo := filepath.Join(tmpDir, "hung.jsonnet")
+	if err := syscall.Mkfifo(fifo, 0o600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	mustWriteFile(t, filepath.Join(tmpDir, "ok.jsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, nil, WithReadTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	if _, _, err := imp.Import("", filepath.Join(tmpDir, "ok.jsonnet")); err != nil {
+		t.Fatalf("Import() of a regular file error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err = imp.WithContext(ctx).Import("", fifo)
+	if !errors.Is(err, ErrReadFile) {
+		t.Fatalf("Import() error = %v, want ErrReadFile", err)
+	}
+	var ie *ImportError
+	if !errors.As(err, &ie) || ie.ResolvedPath != fifo {
+		t.Errorf("Import() error = %#v, want ImportError for %q", err, fifo)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Import() took %v to reject the FIFO", elapsed)
+	}
+
+	if _, err := imp.Resolve("", fifo); !errors.Is(err, ErrReadFile) {
+		t.Errorf("Resolve() error = %v, want ErrReadFile", err)
+	}
+	if _, err := imp.nativeSHA256File([]any{"hung.jsonnet"}); !errors.Is(err, ErrReadFile) {
+		t.Errorf("sha256File() error = %v, want ErrReadFile", err)
+	}
+}
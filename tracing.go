@@ -0,0 +1,58 @@
+package safesonnet
+
+import "context"
+
+// Tracer starts spans around import resolution. Its shape follows the
+// OpenTelemetry tracer so adapters stay thin; see the safesonnetotel package.
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start begins a span named name as a child of any span in ctx and returns
+	// a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// SetAttribute annotates the span. Values are strings, bools or ints.
+	SetAttribute(key string, value any)
+	// End finishes the span, recording err as its status when non-nil.
+	End(err error)
+}
+
+// Span names and attribute keys used by SafeImporter.
+const (
+	SpanImport  = "safesonnet.Import"
+	SpanPrimary = "safesonnet.primary"
+	SpanJPath   = "safesonnet.jpath"
+	SpanRead    = "safesonnet.read"
+
+	AttrImportedFrom = "safesonnet.imported_from"
+	AttrImportedPath = "safesonnet.imported_path"
+	AttrFoundAt      = "safesonnet.found_at"
+	AttrCandidate    = "safesonnet.candidate"
+	AttrJPath        = "safesonnet.jpath"
+	AttrCacheHit     = "safesonnet.cache_hit"
+	AttrFound        = "safesonnet.found"
+	AttrBytes        = "safesonnet.bytes"
+)
+
+// WithTracer creates spans for every Import, its primary lookup, each JPath
+// probe and each file read.
+func WithTracer(t Tracer) Option {
+	return func(s *SafeImporter) {
+		if t != nil {
+			s.tracer = t
+		}
+	}
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, any) {}
+func (nopSpan) End(error)                {}
@@ -0,0 +1,206 @@
+package safesonnet
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/go-jsonnet/ast"
+)
+
+// Bundle is a self-contained copy of an entrypoint and the files it imports,
+// produced by Vendor. It evaluates identically under a SafeImporter rooted at
+// the bundle with no JPaths.
+type Bundle struct {
+	// Entrypoint is the path of the entrypoint within the bundle.
+	Entrypoint string
+	// Files holds every file in the bundle, sorted by path.
+	Files []BundleFile
+}
+
+// BundleFile is a file in a Bundle.
+type BundleFile struct {
+	// Path is slash-separated and relative to the bundle root.
+	Path string
+	// Data is the file contents, with imports rewritten where needed.
+	Data []byte
+}
+
+// importEdit replaces an import path literal.
+type importEdit struct {
+	literal ast.LocationRange
+	path    string
+}
+
+// Vendor collects entrypoint and every file it statically imports, as found
+// by ScanImports, into a Bundle. Files keep their paths relative to the root.
+// Imports that were resolved through a JPath or by an absolute path are
+// rewritten to paths relative to the importing file, so the bundle needs no
+// JPaths. Like ScanImports, Vendor does not run hooks, so the bundle holds the
+// files as they are on disk. Vendoring fails if any import cannot be resolved,
+// or if a file that needs rewriting is also imported with importstr or
+// importbin, since rewriting it would change the imported data.
+func Vendor(importer *SafeImporter, entrypoint string) (*Bundle, error) {
+	res, err := ScanImports(importer, entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	if failed := slices.Concat(res.Violations, res.Unresolved); len(failed) > 0 {
+		si := failed[0]
+
+		return nil, fmt.Errorf("%w: %s:%d:%d: %w", ErrVendor, si.From, si.Line, si.Column, si.Err)
+	}
+
+	contents, foundAt, err := importer.loadStatic("", entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	v := &vendorer{
+		s:     importer,
+		files: map[string][]byte{res.Graph.rel(foundAt): contents.Data()},
+		edits: make(map[string][]importEdit),
+		data:  make(map[string]struct{}),
+	}
+	for _, si := range res.Imports {
+		if err := v.add(si); err != nil {
+			return nil, err
+		}
+	}
+
+	return v.bundle(res.Graph.rel(foundAt))
+}
+
+// vendorer accumulates the files of a Bundle, keyed by root-relative path.
+type vendorer struct {
+	s     *SafeImporter
+	files map[string][]byte
+	edits map[string][]importEdit
+	// data holds the files imported with importstr or importbin.
+	data map[string]struct{}
+}
+
+func (v *vendorer) add(si StaticImport) error {
+	rel := si.Resolved.RelPath
+	if _, ok := v.files[rel]; !ok {
+		contents, _, err := v.s.loadStatic(filepath.Join(v.s.rootAbsPath, si.From), si.Path)
+		if err != nil {
+			return err
+		}
+		v.files[rel] = contents.Data()
+	}
+	if si.Kind != ImportCode {
+		v.data[rel] = struct{}{}
+	}
+	if si.Resolved.JPath != "" || filepath.IsAbs(si.Path) {
+		v.edits[si.From] = append(v.edits[si.From], importEdit{literal: si.literal, path: relativeImport(si.From, rel)})
+	}
+
+	return nil
+}
+
+func (v *vendorer) bundle(entry string) (*Bundle, error) {
+	b := &Bundle{Entrypoint: filepath.ToSlash(entry)}
+	for rel, src := range v.files {
+		if _, ok := v.data[rel]; ok && len(v.edits[rel]) > 0 {
+			return nil, fmt.Errorf("%w: %s is imported as data but its imports need rewriting", ErrVendor, rel)
+		}
+		b.Files = append(b.Files, BundleFile{Path: filepath.ToSlash(rel), Data: rewriteImports(src, v.edits[rel])})
+	}
+	slices.SortFunc(b.Files, func(a, b BundleFile) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	return b, nil
+}
+
+// WriteDir writes the bundle into the output directory of w, unchanged by
+// its output format.
+func (b *Bundle) WriteDir(w *OutputWriter) error {
+	for _, f := range b.Files {
+		rel, err := outputPath(f.Path)
+		if err != nil {
+			return err
+		}
+		if err := w.put(rel, f.Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteTar writes the bundle to w as a tar archive. Headers carry no
+// timestamps or owners, so the archive is reproducible.
+func (b *Bundle) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	for _, f := range b.Files {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.Path,
+			Mode:     int64(DefaultOutputFileMode),
+			Size:     int64(len(f.Data)),
+			ModTime:  time.Unix(0, 0),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// relativeImport returns the slash-separated import path of to from the file
+// from, both relative to the root.
+func relativeImport(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return filepath.ToSlash(to)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// rewriteImports returns a copy of src with the path literals of edits
+// replaced. Locations use byte columns, as reported by the go-jsonnet parser.
+func rewriteImports(src []byte, edits []importEdit) []byte {
+	if len(edits) == 0 {
+		return bytes.Clone(src)
+	}
+
+	lineStarts := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(line, column int) int {
+		return lineStarts[line-1] + column - 1
+	}
+
+	slices.SortFunc(edits, func(a, b importEdit) int {
+		return cmp.Compare(offset(a.literal.Begin.Line, a.literal.Begin.Column),
+			offset(b.literal.Begin.Line, b.literal.Begin.Column))
+	})
+
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		out.Write(src[last:offset(e.literal.Begin.Line, e.literal.Begin.Column)])
+		// A JSON string is a valid Jsonnet string literal.
+		quoted, _ := json.Marshal(e.path)
+		out.Write(quoted)
+		last = offset(e.literal.End.Line, e.literal.End.Column)
+	}
+	out.Write(src[last:])
+
+	return out.Bytes()
+}
@@ -0,0 +1,156 @@
+package safesonnet
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVendor(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "app", "main.jsonnet"), `local k = import 'k8s/lib.libsonnet';
+{
+  lib: k,
+  shared: import '`+filepath.Join(tmpDir, "shared.libsonnet")+`',
+  local_: import 'local.libsonnet',
+  text: importstr "k8s/data.txt",
+}
+`)
+	mustWriteFile(t, filepath.Join(tmpDir, "app", "local.libsonnet"), `{ here: true }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "k8s", "lib.libsonnet"), `{ util: import 'util.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "k8s", "util.libsonnet"), `{ name: 'util', x: import 'x.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "k8s", "data.txt"), `some data`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib", "x.libsonnet"), `'x'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "shared.libsonnet"), `{ shared: true }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "unused.libsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"vendor", "lib"})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	want, err := NewVM(imp, VMOptions{}).EvaluateFile(filepath.Join(tmpDir, "app", "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+
+	b, err := Vendor(imp, filepath.Join(tmpDir, "app", "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("Vendor() error = %v", err)
+	}
+	if b.Entrypoint != "app/main.jsonnet" {
+		t.Errorf("Entrypoint = %q, want app/main.jsonnet", b.Entrypoint)
+	}
+
+	var paths []string
+	for _, f := range b.Files {
+		paths = append(paths, f.Path)
+	}
+	wantPaths := []string{
+		"app/local.libsonnet",
+		"app/main.jsonnet",
+		"lib/x.libsonnet",
+		"shared.libsonnet",
+		"vendor/k8s/data.txt",
+		"vendor/k8s/lib.libsonnet",
+		"vendor/k8s/util.libsonnet",
+	}
+	if !slices.Equal(paths, wantPaths) {
+		t.Errorf("Files = %v, want %v", paths, wantPaths)
+	}
+
+	main := string(b.Files[1].Data)
+	for _, rewritten := range []string{`import "../vendor/k8s/lib.libsonnet"`, `import "../shared.libsonnet"`,
+		`importstr "../vendor/k8s/data.txt"`, `import 'local.libsonnet'`} {
+		if !strings.Contains(main, rewritten) {
+			t.Errorf("main.jsonnet = %s, want it to contain %s", main, rewritten)
+		}
+	}
+
+	outDir := t.TempDir()
+	w, err := NewOutputWriter(outDir)
+	if err != nil {
+		t.Fatalf("NewOutputWriter() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	if err := b.WriteDir(w); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+
+	vendored, err := NewSafeImporter(outDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { vendored.Close() })
+
+	got, err := NewVM(vendored, VMOptions{}).EvaluateFile(filepath.Join(outDir, "app", "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFile() of the vendored tree error = %v", err)
+	}
+	if got != want {
+		t.Errorf("vendored output = %s, want %s", got, want)
+	}
+
+	var archive bytes.Buffer
+	if err := b.WriteTar(&archive); err != nil {
+		t.Fatalf("WriteTar() error = %v", err)
+	}
+	tr := tar.NewReader(&archive)
+	for _, f := range b.Files {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("tar ReadAll() error = %v", err)
+		}
+		if hdr.Name != f.Path || !bytes.Equal(data, f.Data) {
+			t.Errorf("tar entry %q does not match bundle file %q", hdr.Name, f.Path)
+		}
+	}
+	if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("tar has extra entries: %v", err)
+	}
+}
+
+func TestVendor_Errors(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "escape.jsonnet"), `import '../outside.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "missing.jsonnet"), `if false then import 'missing.libsonnet' else {}`)
+	mustWriteFile(t, filepath.Join(tmpDir, "both.jsonnet"),
+		`{ code: import 'lib/a.libsonnet', text: importstr 'lib/a.libsonnet' }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "vendor", "lib", "a.libsonnet"), `import 'b.libsonnet'`)
+	mustWriteFile(t, filepath.Join(tmpDir, "b.libsonnet"), `{}`)
+
+	imp, err := NewSafeImporter(tmpDir, []string{"vendor", "."})
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	t.Cleanup(func() { imp.Close() })
+
+	tests := []struct {
+		file    string
+		wantErr error
+	}{
+		{file: "escape.jsonnet", wantErr: ErrForbiddenRelativePathTraversal},
+		{file: "missing.jsonnet", wantErr: ErrFileNotFound},
+		{file: "both.jsonnet", wantErr: ErrVendor},
+	}
+	for _, tt := range tests {
+		_, err := Vendor(imp, filepath.Join(tmpDir, tt.file))
+		if !errors.Is(err, ErrVendor) || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Vendor(%s) error = %v, want ErrVendor and %v", tt.file, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package safesonnet
+
+import (
+	"io"
+
+	"github.com/google/go-jsonnet"
+)
+
+// DefaultMaxStack is the evaluation stack depth NewVM uses unless
+// VMOptions.MaxStack is set.
+const DefaultMaxStack = 500
+
+// VMOptions customizes the VM returned by NewVM. The zero value gives the
+// secure defaults: DefaultMaxStack, no native functions, no external variables
+// or top-level arguments, and std.trace output discarded.
+type VMOptions struct {
+	// MaxStack limits the evaluation stack depth. Zero means DefaultMaxStack.
+	MaxStack int
+	// NativeFunctions are made available through std.native.
+	NativeFunctions []*jsonnet.NativeFunction
+	// ExtVars and ExtCodes set external variables as strings and as code.
+	ExtVars  map[string]string
+	ExtCodes map[string]string
+	// TLAVars and TLACodes set top-level arguments as strings and as code.
+	TLAVars  map[string]string
+	TLACodes map[string]string
+	// TraceOut receives std.trace output. Nil discards it, so evaluated code
+	// cannot write to the process's stderr.
+	TraceOut io.Writer
+	// StringOutput makes the VM output a string result directly rather than
+	// as JSON.
+	StringOutput bool
+}
+
+// NewVM returns a VM that imports through importer and is configured from
+// opts. Use it instead of jsonnet.MakeVM so that no default file importer or
+// inherited configuration is left in place.
+func NewVM(importer *SafeImporter, opts VMOptions) *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	vm.Importer(importer)
+	applyVMOptions(vm, opts)
+
+	return vm
+}
+
+func applyVMOptions(vm *jsonnet.VM, opts VMOptions) {
+	vm.MaxStack = DefaultMaxStack
+	if opts.MaxStack > 0 {
+		vm.MaxStack = opts.MaxStack
+	}
+	vm.StringOutput = opts.StringOutput
+
+	traceOut := opts.TraceOut
+	if traceOut == nil {
+		traceOut = io.Discard
+	}
+	vm.SetTraceOut(traceOut)
+
+	vm.ExtReset()
+	for k, v := range opts.ExtVars {
+		vm.ExtVar(k, v)
+	}
+	for k, v := range opts.ExtCodes {
+		vm.ExtCode(k, v)
+	}
+
+	vm.TLAReset()
+	for k, v := range opts.TLAVars {
+		vm.TLAVar(k, v)
+	}
+	for k, v := range opts.TLACodes {
+		vm.TLACode(k, v)
+	}
+
+	for _, f := range opts.NativeFunctions {
+		vm.NativeFunction(f)
+	}
+}
@@ -0,0 +1,70 @@
+package safesonnet
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+func TestNewVM(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(tmpDir, "main.jsonnet"),
+		`function(name) std.trace('evaluating', { name: name, env: std.extVar('env'), lib: import 'lib.libsonnet' })`)
+	mustWriteFile(t, filepath.Join(tmpDir, "lib.libsonnet"), `{ lib: true }`)
+	mustWriteFile(t, filepath.Join(tmpDir, "recurse.jsonnet"), `local f(n) = if n == 0 then 0 else 1 + f(n - 1); f(100)`)
+	mustWriteFile(t, filepath.Join(tmpDir, "native.jsonnet"), `std.native('double')(21)`)
+
+	imp, err := NewSafeImporter(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewSafeImporter() error = %v", err)
+	}
+	defer imp.Close()
+
+	var trace bytes.Buffer
+	vm := NewVM(imp, VMOptions{
+		ExtVars:  map[string]string{"env": "prod"},
+		TLAVars:  map[string]string{"name": "demo"},
+		TraceOut: &trace,
+	})
+	out, err := vm.EvaluateFile(filepath.Join(tmpDir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("EvaluateFile() error = %v", err)
+	}
+	for _, want := range []string{`"env": "prod"`, `"name": "demo"`, `"lib": true`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("EvaluateFile() = %s, want it to contain %s", out, want)
+		}
+	}
+	if !strings.Contains(trace.String(), "evaluating") {
+		t.Errorf("trace output = %q, want the std.trace message", trace.String())
+	}
+
+	if _, err := NewVM(imp, VMOptions{MaxStack: 10}).EvaluateFile(filepath.Join(tmpDir, "recurse.jsonnet")); err == nil {
+		t.Error("EvaluateFile() with MaxStack 10 should exceed the stack")
+	}
+
+	if _, err := NewVM(imp, VMOptions{}).EvaluateFile(filepath.Join(tmpDir, "native.jsonnet")); err == nil {
+		t.Error("EvaluateFile() should not find native functions by default")
+	}
+
+	double := &jsonnet.NativeFunction{
+		Name:   "double",
+		Params: ast.Identifiers{"x"},
+		Func: func(args []any) (any, error) {
+			x, _ := args[0].(float64)
+
+			return x * 2, nil
+		},
+	}
+	out, err = NewVM(imp, VMOptions{NativeFunctions: []*jsonnet.NativeFunction{double}}).
+		EvaluateFile(filepath.Join(tmpDir, "native.jsonnet"))
+	if err != nil || strings.TrimSpace(out) != "42" {
+		t.Errorf("EvaluateFile() with native function = %q, %v", out, err)
+	}
+}